@@ -3,176 +3,173 @@ package main
 // Entry point for author server
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log"
 	"net"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
 
+	authgrpc "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/grpc"
 	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/routing"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/wsockets"
 	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/wsockets/robotmanager"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
 	"github.com/joho/godotenv"
-	"github.com/supabase-community/supabase-go"
-	"google.golang.org/grpc"
 
 	pb "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/proto"
 )
 
-type server struct {
-	pb.UnimplementedOrderHandlerServer
-	sb  *supabase.Client
-	orm *matcher.OrderRobotMatcher
-}
-
-func (s *server) InsertOrder(ctx context.Context, req *pb.InsertOrderRequest) (*pb.InsertOrderResponse, error) {
-	fmt.Println("InsertOrder called")
-	order := req.GetOrder()
-
-	// Print all incoming order data
-	fmt.Printf("Received Order:\n")
-	fmt.Printf("  - OrderId: %d\n", order.GetOrderId())
-	fmt.Printf("  - UserId: %s\n", order.GetUserId())
-	fmt.Printf("  - VendorId: %s\n", order.GetVendorId())
-	fmt.Printf("  - Status: %s\n", order.GetStatus())
-	fmt.Printf("  - DropoffLocId: %s\n", order.GetDropoffLocId())
-	fmt.Printf("  - RobotId: '%s' (length: %d)\n", order.GetRobotId(), len(order.GetRobotId()))
-	fmt.Printf("  - Items count: %d\n", len(order.GetItems()))
-
-	// Prepare base order data
-	orderData := map[string]interface{}{
-		"userId":          order.GetUserId(),
-		"vendorId":        order.GetVendorId(),
-		"status":          order.GetStatus(),
-		"dropOffLocation": order.GetDropoffLocId(),
-	}
-
-	// Only add robotId if it's not empty (protobuf default for string is "")
-	// Don't insert empty string into UUID column
-	if order.GetRobotId() != "" {
-		orderData["robotId"] = order.GetRobotId()
-	} else {
-		orderData["robotId"] = nil
-	}
-	// If robotId is empty, the database will use NULL as default
+func main() {
+	godotenv.Load("../../.env")
 
-	// INSERT ORDER INTO "Orders" TABLE
-	inserted, _, err := s.sb.
-		From("orders").
-		Insert(orderData, false, "representation", "", "").
-		Execute()
+	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
-		return nil, fmt.Errorf("failed inserting order: %v", err)
-	}
-
-	// extract the order ID from the DB
-	var rows []struct {
-		OrderID int64 `json:"id"`
+		log.Fatalf("failed to listen: %v", err)
 	}
-
-	err = json.Unmarshal(inserted, &rows)
+	// lets us A/B matching policies without touching the engine
+	strategy, err := matcher.StrategyByName(os.Getenv("MATCH_STRATEGY"))
 	if err != nil {
-		return nil, fmt.Errorf("failed unmarshaling order response: %v", err)
+		log.Fatalf("failed to pick match strategy: %v", err)
 	}
+	log.Printf("using %s match strategy", strategy.Name())
 
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("no order ID returned from database")
-	}
-
-	orderId := rows[0].OrderID
-	order.OrderId = orderId
-
-	// ok time to insert items in order into orderitems table
-	for _, item := range order.GetItems() {
-		itemData := map[string]interface{}{
-			"orderId":  orderId,
-			"itemName": item.GetItemName(),
-			"quantity": item.GetQuantity(),
-			"price":    item.GetPrice(),
-		}
-
-		_, _, err := s.sb.
-			From("orderItems").
-			Insert(itemData, false, "", "", "").
-			Execute()
-		if err != nil {
-			return nil, fmt.Errorf("failed inserting order item: %v", err)
-		}
-	}
+	// ctrl-c / SIGTERM stops the matcher, which in turn stops the robot manager
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// insert into order queue to prepare for matching with robot
-	order_element := matcher.CreateOrder(order.GetUserId(), int(order.GetOrderId()), 0) // 0 for now as it will get updated in engine.go
-	s.orm.SubmitOrder(order_element)
+	// the hub and matcher both move robots through this
+	fleet := robots.NewManager()
 
-	return &pb.InsertOrderResponse{
-		Order:     order,
-		ReturnMsg: "SUCCESS",
-	}, nil
-}
-func (s *server) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error) {
-	order := req.GetOrder()
-	orderId := order.GetOrderId()
-
-	// Delete order items first due to foreign key constraints
-	_, _, err := s.sb.
-		From("orderItems").
-		Delete("", "").
-		Eq("orderId", strconv.Itoa(int(orderId))).
-		Execute()
-	if err != nil {
-		return nil, fmt.Errorf("failed deleting order items: %v", err)
-	}
+	// every order status change, from the matcher, robots or here, goes through this
+	database := db.New()
+	states := state.NewManager(database)
 
-	// Delete the order
-	_, _, err = s.sb.
-		From("orders").
-		Delete("", "").
-		Eq("id", strconv.Itoa(int(orderId))).
-		Execute()
+	// robots connect with tokens signed by this, without it anyone could pose as a robot
+	robotAuth, err := security.NewRobotAuth([]byte(os.Getenv("ROBOT_TOKEN_SECRET")), database)
 	if err != nil {
-		return nil, fmt.Errorf("failed deleting order: %v", err)
+		log.Fatalf("failed to set up robot auth: %v", err)
 	}
 
-	return &pb.DeleteOrderResponse{
-		ReturnMsg: "SUCCESS",
-	}, nil
-}
-
-func main() {
-	godotenv.Load("../../.env")
-
-	SUPABASE_URL := os.Getenv("SUPABASE_URL")
-	SUPABASE_KEY := os.Getenv("SUPABASE_KEY")
-
-	client, err := supabase.NewClient(
-		SUPABASE_URL,
-		SUPABASE_KEY,
-		nil,
+	// where every robot is and how it's doing, from its telemetry, positions are written to the database every so often
+	// POSITION_FLUSH_INTERVAL (e.g. 30s) overrides how often
+	live := telemetry.NewStore(liveOpts()...)
+	flushDone := make(chan struct{})
+	go func() {
+		live.Run(ctx, durationEnv("POSITION_FLUSH_INTERVAL", telemetry.DefaultFlushInterval), database)
+		close(flushDone)
+	}()
+
+	orm := matcher.CreateOrderRobotMatcher(
+		matcher.WithStrategy(strategy),
+		matcher.WithRobots(fleet),
+		matcher.WithStore(matcher.NewDBOrderStore(database, states)),
+		matcher.WithPositions(live),
+		matcher.WithStatuses(states),
 	)
+	match := orm.StartORM(ctx)
+
+	// pick up every order that was waiting on a robot when we last went down
+	restored, err := orm.Restore(ctx)
 	if err != nil {
-		log.Fatalf("failed to create supabase client: %v", err)
+		log.Fatalf("failed to restore order queue: %v", err)
 	}
+	log.Printf("restored %d unmatched orders", restored)
 
-	lis, err := net.Listen("tcp", ":50051")
+	// drains survive restarts, robots marked draining in the database stay off new routes
+	fleetServer := authgrpc.NewFleetServer(database, fleet, orm, robotAuth, authgrpc.WithLiveStore(live))
+	draining, err := fleetServer.Restore(ctx)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("failed to restore draining robots: %v", err)
 	}
-	orm := matcher.CreateOrderRobotMatcher()
-	match := orm.StartORM()
+	log.Printf("restored %d draining robots", draining)
 
 	log.Println("starting robot manager...")
-	go robotmanager.StartRobotManager(orm, match)
+	robotManagerDone := make(chan struct{})
+	go func() {
+		robotmanager.StartRobotManager(ctx, orm, match, fleet, states, robotAuth, append(hubOpts(), wsockets.WithLiveStore(live))...)
+		close(robotManagerDone)
+	}()
 
 	log.Println("robot manager started!")
-	grpc_server := grpc.NewServer()
-	pb.RegisterOrderHandlerServer(grpc_server, &server{
-		sb:  client,
-		orm: orm,
-	})
+	// callers prove who they are with their supabase jwt, trusted backends use the service role key
+	callerAuth, err := authgrpc.NewAuthenticator([]byte(os.Getenv("SUPABASE_JWT_SECRET")))
+	if err != nil {
+		log.Fatalf("failed to set up caller auth: %v", err)
+	}
+	grpc_server := authgrpc.NewServer(callerAuth)
+	pb.RegisterOrderHandlerServer(grpc_server, authgrpc.NewOrderServer(database, orm, states, append(orderServerOpts(), authgrpc.WithEstimator(routing.NewEstimator(live)))...))
+	pb.RegisterFleetAdminServer(grpc_server, fleetServer)
+
+	go func() {
+		<-ctx.Done()
+		log.Println("shutting down gRPC server...")
+		grpc_server.GracefulStop()
+	}()
 
 	log.Println("gRPC server listening on :50051")
 
 	if err := grpc_server.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+
+	<-robotManagerDone
+	<-flushDone
+	unmatched := orm.Wait()
+	for _, o := range unmatched.Orders { // still queued in the database, Restore picks them up next start
+		log.Printf("order %d was never matched", o.OrderID())
+	}
+	log.Println("shutdown complete")
+}
+
+// IDEMPOTENCY_WINDOW (e.g. 1h) overrides how long InsertOrder retries get the first order back
+func orderServerOpts() []authgrpc.OrderServerOption {
+	return []authgrpc.OrderServerOption{
+		authgrpc.WithIdempotencyWindow(durationEnv("IDEMPOTENCY_WINDOW", authgrpc.DefaultIdempotencyWindow)),
+	}
+}
+
+// ASSIGNMENT_ACK_TIMEOUT (e.g. 15s) overrides how long robots have to ack a route
+// UNHEALTHY_COOLDOWN (e.g. 2m) overrides how long a robot that rejected or missed one sits out
+// WS_PONG_WAIT, WS_WRITE_WAIT and HEARTBEAT_INTERVAL override the robot liveness deadlines
+// RESUME_WINDOW overrides how long a robot that dropped mid-delivery has to come back
+// ASSIGNMENT_BLOCK_TIMEOUT overrides how long an assignment waits for room in a slow robot's queue
+func hubOpts() []wsockets.HubOption {
+	return []wsockets.HubOption{
+		wsockets.WithAckTimeout(durationEnv("ASSIGNMENT_ACK_TIMEOUT", wsockets.DefaultAckTimeout)),
+		wsockets.WithUnhealthyCooldown(durationEnv("UNHEALTHY_COOLDOWN", wsockets.DefaultUnhealthyCooldown)),
+		wsockets.WithPongWait(durationEnv("WS_PONG_WAIT", wsockets.DefaultPongWait)),
+		wsockets.WithWriteWait(durationEnv("WS_WRITE_WAIT", wsockets.DefaultWriteWait)),
+		wsockets.WithHeartbeatInterval(durationEnv("HEARTBEAT_INTERVAL", wsockets.DefaultHeartbeatInterval)),
+		wsockets.WithResumeWindow(durationEnv("RESUME_WINDOW", wsockets.DefaultResumeWindow)),
+		wsockets.WithBackpressure(wsockets.ClassAssignment, wsockets.BlockWithTimeout(
+			wsockets.DefaultAssignmentBuffer,
+			durationEnv("ASSIGNMENT_BLOCK_TIMEOUT", wsockets.DefaultAssignmentBlock),
+		)),
+	}
+}
+
+// POSITION_TTL (e.g. 1m) overrides how long a robot's telemetry is trusted
+func liveOpts() []telemetry.Option {
+	return []telemetry.Option{
+		telemetry.WithTTL(durationEnv("POSITION_TTL", telemetry.DefaultTTL)),
+	}
+}
+
+// the duration in env, fallback if it isn't set
+func durationEnv(env string, fallback time.Duration) time.Duration {
+	v := os.Getenv(env)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", env, err)
+	}
+	return parsed
 }
@@ -1,3 +1,798 @@
 package grpc
 
 // comms between backend and author server
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/routing"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/proto"
+)
+
+// how long a retried InsertOrder gets the first order back instead of placing a new one
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+type OrderServer struct {
+	pb.UnimplementedOrderHandlerServer
+	store             db.Store
+	orm               *matcher.OrderRobotMatcher
+	states            *state.Manager
+	idempotencyWindow time.Duration
+	eta               *routing.Estimator // nil leaves GetOrder without an eta
+}
+
+type OrderServerOption func(*OrderServer)
+
+func WithIdempotencyWindow(window time.Duration) OrderServerOption {
+	return func(s *OrderServer) {
+		s.idempotencyWindow = window
+	}
+}
+
+// has GetOrder estimate when the robot carrying the order gets to the drop-off
+func WithEstimator(eta *routing.Estimator) OrderServerOption {
+	return func(s *OrderServer) {
+		s.eta = eta
+	}
+}
+
+func NewOrderServer(store db.Store, orm *matcher.OrderRobotMatcher, states *state.Manager, opts ...OrderServerOption) *OrderServer {
+	s := &OrderServer{
+		store:             store,
+		orm:               orm,
+		states:            states,
+		idempotencyWindow: DefaultIdempotencyWindow,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// finds where the robot picks up (vendor) and drops off the order
+func (s *OrderServer) orderLocations(ctx context.Context, vendorID string, dropoffLocID string) (geo.Point, geo.Point, error) {
+	vendor, err := s.store.GetVendor(ctx, vendorID)
+	if err != nil {
+		return geo.Point{}, geo.Point{}, err
+	}
+
+	vendorLoc, err := s.store.GetCoordinate(ctx, vendor.Coordinates)
+	if err != nil {
+		return geo.Point{}, geo.Point{}, err
+	}
+
+	dropoffLoc, err := s.store.GetCoordinate(ctx, dropoffLocID)
+	if err != nil {
+		return geo.Point{}, geo.Point{}, err
+	}
+
+	return vendorLoc.Point(), dropoffLoc.Point(), nil
+}
+
+func (s *OrderServer) InsertOrder(ctx context.Context, req *pb.InsertOrderRequest) (*pb.InsertOrderResponse, error) {
+	fmt.Println("InsertOrder called")
+	order := req.GetOrder()
+
+	// Print all incoming order data
+	fmt.Printf("Received Order:\n")
+	fmt.Printf("  - OrderId: %d\n", order.GetOrderId())
+	fmt.Printf("  - UserId: %s\n", order.GetUserId())
+	fmt.Printf("  - VendorId: %s\n", order.GetVendorId())
+	fmt.Printf("  - Status: %s\n", order.GetStatus())
+	fmt.Printf("  - DropoffLocId: %s\n", order.GetDropoffLocId())
+	fmt.Printf("  - RobotId: '%s' (length: %d)\n", order.GetRobotId(), len(order.GetRobotId()))
+	fmt.Printf("  - Priority: %s\n", order.GetPriority())
+	fmt.Printf("  - Items count: %d\n", len(order.GetItems()))
+
+	// users place orders for themselves, only trusted backends may name someone else
+	if id, _ := IdentityFrom(ctx); order.GetUserId() == "" && !id.ServiceRole {
+		order.UserId = id.UserID
+	}
+	if err := authorizeUser(ctx, order.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	key, err := idempotencyKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if resp, err := s.replayInsert(ctx, order, key); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	// resolve locations before writing anything so a bad vendor or dropoff never reaches the matcher
+	vendorLoc, dropoffLoc, err := s.orderLocations(ctx, order.GetVendorId(), order.GetDropoffLocId())
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]db.OrderItem, 0, len(order.GetItems()))
+	for _, item := range order.GetItems() {
+		items = append(items, db.OrderItem{
+			ItemName: item.GetItemName(),
+			Quantity: int(item.GetQuantity()),
+			Price:    item.GetPrice(),
+		})
+	}
+
+	// order and items go in together, a bad item leaves nothing behind
+	inserted, err := s.store.CreateOrderWithItems(ctx, db.Order{
+		UserID:          order.GetUserId(),
+		VendorID:        order.GetVendorId(),
+		Status:          db.OrderStatusPending, // matcher moves it along from here
+		Priority:        int(order.GetPriority()),
+		DropOffLocation: order.GetDropoffLocId(),
+		RobotID:         order.GetRobotId(), // left out when empty, the database uses NULL
+		IdempotencyKey:  key,
+	}, items)
+	if errors.Is(err, db.ErrAlreadyExists) && key != "" { // a retry got in between, it placed the order
+		if resp, err := s.replayInsert(ctx, order, key); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	orderId := inserted.ID
+	order.OrderId = orderId
+
+	s.states.Created(orderId)
+
+	// insert into order queue to prepare for matching with robot
+	order_element := matcher.CreateOrder(order.GetUserId(), int(order.GetOrderId()), 0, vendorLoc, dropoffLoc, matcher.Priority(order.GetPriority())) // 0 for now as it will get updated in engine.go
+	if err := s.orm.SubmitOrder(order_element); err != nil {
+		return nil, fmt.Errorf("failed submitting order to matcher: %v", err)
+	}
+
+	return &pb.InsertOrderResponse{
+		Order:     orderToProto(inserted, items),
+		ReturnMsg: "SUCCESS",
+	}, nil
+}
+
+// the key can come in the request or as idempotency-key metadata, so retries can be keyed without touching the body
+func idempotencyKey(ctx context.Context, req *pb.InsertOrderRequest) (string, error) {
+	key := req.GetIdempotencyKey()
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("idempotency-key"); len(values) > 0 {
+		if key != "" && key != values[0] {
+			return "", status.Error(codes.InvalidArgument, "idempotency key in the request and metadata don't match")
+		}
+		key = values[0]
+	}
+	return key, nil
+}
+
+// answers a retry with the order the key already placed, nil if there's nothing to replay and the order should go in
+func (s *OrderServer) replayInsert(ctx context.Context, order *pb.Order, key string) (*pb.InsertOrderResponse, error) {
+	existing, err := s.store.GetOrderByIdempotencyKey(ctx, order.GetUserId(), key)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339Nano, existing.CreatedAt); err == nil && time.Since(createdAt) > s.idempotencyWindow {
+		// too long ago to be a retry, the key is free for this order
+		if err := s.store.ClearIdempotencyKey(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if existing.VendorID != order.GetVendorId() || existing.DropOffLocation != order.GetDropoffLocId() {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key %q was already used for a different order", key)
+	}
+
+	items, err := s.store.GetOrderItems(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("replaying InsertOrder for idempotency key %q, order %d", key, existing.ID)
+	return &pb.InsertOrderResponse{
+		Order:     orderToProto(existing, items),
+		ReturnMsg: "SUCCESS",
+	}, nil
+}
+
+func (s *OrderServer) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error) {
+	order := req.GetOrder()
+	orderId := order.GetOrderId()
+
+	if err := s.authorizeOrder(ctx, orderId); err != nil {
+		return nil, err
+	}
+
+	// once it has arrived the robot is already handing it over
+	err := s.states.Apply(ctx, state.Event{OrderID: orderId, To: state.StatusCancelled, Source: state.SourceGRPC})
+	if errors.Is(err, state.ErrInvalidTransition) {
+		if status, _ := s.states.Status(ctx, orderId); !status.Terminal() {
+			return nil, fmt.Errorf("order %d can no longer be cancelled: %v", orderId, err)
+		}
+		// already finished, deleting it just cleans up
+	} else if err != nil {
+		return nil, fmt.Errorf("failed cancelling order: %v", err)
+	}
+
+	// stop the matcher from handing it out, or recall the robot that already has it
+	result, err := s.orm.CancelOrder(int(orderId))
+	if err != nil {
+		return nil, fmt.Errorf("failed cancelling order in matcher: %v", err)
+	}
+	if result == matcher.CancelRecalled {
+		fmt.Printf("recalled robot for cancelled order %d\n", orderId)
+	}
+
+	if err := s.store.DeleteOrder(ctx, orderId); err != nil {
+		return nil, err
+	}
+	s.states.Deleted(orderId)
+
+	return &pb.DeleteOrderResponse{
+		ReturnMsg: "SUCCESS",
+	}, nil
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// statuses anyone outside the matcher may set, queued and matched only come from matching
+var settableStatuses = map[state.Status]bool{
+	state.StatusPickedUp:  true,
+	state.StatusInTransit: true,
+	state.StatusArrived:   true,
+	state.StatusDelivered: true,
+	state.StatusCancelled: true,
+	state.StatusFailed:    true,
+}
+
+// gives callers a proper code instead of Unknown for the errors they can do something about
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, db.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, state.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+func orderToProto(o db.Order, items []db.OrderItem) *pb.Order {
+	order := &pb.Order{
+		OrderId:      o.ID,
+		UserId:       o.UserID,
+		VendorId:     o.VendorID,
+		Status:       state.Status(o.Status).String(),
+		DropoffLocId: o.DropOffLocation,
+		RobotId:      o.RobotID,
+		Priority:     pb.OrderPriority(o.Priority),
+	}
+	if createdAt, err := time.Parse(time.RFC3339Nano, o.CreatedAt); err == nil {
+		order.CreatedAt = timestamppb.New(createdAt)
+	}
+	for _, item := range items {
+		itemId, _ := strconv.ParseInt(item.ID, 10, 64) // uuid ids don't fit, those stay 0
+		order.Items = append(order.Items, &pb.OrderItem{
+			ItemId:   itemId,
+			ItemName: item.ItemName,
+			Quantity: int32(item.Quantity),
+			Price:    item.Price,
+		})
+	}
+	return order
+}
+
+func (s *OrderServer) getOrder(ctx context.Context, orderId int64) (*pb.Order, error) {
+	o, err := s.store.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	items, err := s.store.GetOrderItems(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	return orderToProto(o, items), nil
+}
+
+// only the user who placed the order, or a trusted backend, gets to see or touch it
+func (s *OrderServer) authorizeOrder(ctx context.Context, orderId int64) error {
+	o, err := s.store.GetOrder(ctx, orderId)
+	if err != nil {
+		return grpcError(err)
+	}
+	return authorizeUser(ctx, o.UserID)
+}
+
+func (s *OrderServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	order, err := s.getOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeUser(ctx, order.GetUserId()); err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderResponse{Order: order, EtaSeconds: s.orderETA(ctx, order)}, nil
+}
+
+// seconds until the order's robot reaches the drop-off, going by the vendor first if it hasn't picked it up yet
+// 0 if nobody has it, it's already there or we don't know where its robot is
+func (s *OrderServer) orderETA(ctx context.Context, order *pb.Order) int32 {
+	status, err := state.ParseStatus(order.GetStatus())
+	if s.eta == nil || order.GetRobotId() == "" || err != nil {
+		return 0
+	}
+	if status != state.StatusMatched && status != state.StatusPickedUp && status != state.StatusInTransit {
+		return 0
+	}
+
+	vendorLoc, dropoffLoc, err := s.orderLocations(ctx, order.GetVendorId(), order.GetDropoffLocId())
+	if err != nil {
+		log.Printf("failed estimating eta of order %d: %v", order.GetOrderId(), err)
+		return 0
+	}
+	stops := []geo.Point{dropoffLoc}
+	if status == state.StatusMatched {
+		stops = []geo.Point{vendorLoc, dropoffLoc}
+	}
+	eta, ok := s.eta.ETA(order.GetRobotId(), stops...)
+	if !ok {
+		return 0
+	}
+	return int32(eta.Round(time.Second) / time.Second)
+}
+
+// turns the filter and page token into db options, asks for one extra row to know if there is another page
+func listOptions(filter *pb.OrderFilter, pageSize int32, pageToken string) (db.ListOptions, int, error) {
+	size := int(pageSize)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	opts := db.ListOptions{Limit: size + 1}
+	if pageToken != "" {
+		afterID, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil {
+			return db.ListOptions{}, 0, status.Errorf(codes.InvalidArgument, "bad page token %q", pageToken)
+		}
+		opts.AfterID = afterID
+	}
+
+	for _, name := range filter.GetStatuses() {
+		st, err := state.ParseStatus(name)
+		if err != nil {
+			return db.ListOptions{}, 0, status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts.Statuses = append(opts.Statuses, string(st))
+	}
+	if filter.GetCreatedAfter() != nil {
+		opts.CreatedAfter = filter.GetCreatedAfter().AsTime()
+	}
+	if filter.GetCreatedBefore() != nil {
+		opts.CreatedBefore = filter.GetCreatedBefore().AsTime()
+	}
+	return opts, size, nil
+}
+
+func listResponse(orders []db.Order, size int) *pb.ListOrdersResponse {
+	resp := &pb.ListOrdersResponse{}
+	if len(orders) > size {
+		orders = orders[:size]
+		resp.NextPageToken = strconv.FormatInt(orders[size-1].ID, 10)
+	}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, orderToProto(o, nil))
+	}
+	return resp
+}
+
+func (s *OrderServer) ListOrdersByUser(ctx context.Context, req *pb.ListOrdersByUserRequest) (*pb.ListOrdersResponse, error) {
+	if err := authorizeUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	opts, size, err := listOptions(req.GetFilter(), req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.store.ListOrdersByUser(ctx, req.GetUserId(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return listResponse(orders, size), nil
+}
+
+// there's no tie between users and the vendors they work for yet, so only trusted backends can list a vendor's orders
+func (s *OrderServer) ListOrdersByVendor(ctx context.Context, req *pb.ListOrdersByVendorRequest) (*pb.ListOrdersResponse, error) {
+	if err := authorizeServiceRole(ctx); err != nil {
+		return nil, err
+	}
+	opts, size, err := listOptions(req.GetFilter(), req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.store.ListOrdersByVendor(ctx, req.GetVendorId(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return listResponse(orders, size), nil
+}
+
+func (s *OrderServer) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
+	orderId := req.GetOrderId()
+
+	to, err := state.ParseStatus(req.GetStatus())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !settableStatuses[to] {
+		return nil, status.Errorf(codes.InvalidArgument, "status %s is set by the matcher", to)
+	}
+
+	// users can cancel their own orders, moving a delivery along is up to trusted backends
+	if to == state.StatusCancelled {
+		err = s.authorizeOrder(ctx, orderId)
+	} else {
+		err = authorizeServiceRole(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.states.Apply(ctx, state.Event{OrderID: orderId, To: to, Source: state.SourceGRPC}); err != nil {
+		return nil, grpcError(err)
+	}
+
+	// a cancelled order shouldn't stay in the queue or with its robot
+	if to == state.StatusCancelled {
+		if _, err := s.orm.CancelOrder(int(orderId)); err != nil {
+			return nil, fmt.Errorf("failed cancelling order in matcher: %v", err)
+		}
+	}
+
+	order, err := s.getOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateOrderStatusResponse{
+		Order:     order,
+		ReturnMsg: "SUCCESS",
+	}, nil
+}
+
+func updateToProto(u state.Update) *pb.OrderUpdate {
+	update := &pb.OrderUpdate{
+		OrderId:   u.OrderID,
+		Status:    u.Status.String(),
+		RobotId:   u.RobotID,
+		UpdatedAt: timestamppb.New(u.At),
+	}
+	if u.HasPosition {
+		update.RobotPosition = &pb.RobotPosition{X: int32(u.Position.X), Y: int32(u.Position.Y)}
+	}
+	return update
+}
+
+func (s *OrderServer) WatchOrder(req *pb.WatchOrderRequest, stream pb.OrderHandler_WatchOrderServer) error {
+	ctx := stream.Context()
+	orderId := req.GetOrderId()
+
+	o, err := s.store.GetOrder(ctx, orderId)
+	if err != nil {
+		return grpcError(err)
+	}
+	if err := authorizeUser(ctx, o.UserID); err != nil {
+		return err
+	}
+
+	updates, stop, err := s.states.Watch(ctx, orderId)
+	if err != nil {
+		return grpcError(err)
+	}
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case u, ok := <-updates:
+			if !ok { // terminal status went out already
+				return nil
+			}
+			if err := stream.Send(updateToProto(u)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lets operators see and steer the fleet, live state comes from the fleet manager and the rest from the robots table
+type FleetServer struct {
+	pb.UnimplementedFleetAdminServer
+	store db.Store
+	fleet *robots.Manager
+	orm   *matcher.OrderRobotMatcher
+	auth  *security.RobotAuth
+	live  *telemetry.Store // nil leaves robots without a live position
+}
+
+type FleetServerOption func(*FleetServer)
+
+// fills in each robot's position and vitals from its telemetry
+func WithLiveStore(live *telemetry.Store) FleetServerOption {
+	return func(s *FleetServer) {
+		s.live = live
+	}
+}
+
+func NewFleetServer(store db.Store, fleet *robots.Manager, orm *matcher.OrderRobotMatcher, auth *security.RobotAuth, opts ...FleetServerOption) *FleetServer {
+	s := &FleetServer{
+		store: store,
+		fleet: fleet,
+		orm:   orm,
+		auth:  auth,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// marks every robot the database has as draining as such again, call before robots start connecting
+func (s *FleetServer) Restore(ctx context.Context) (int, error) {
+	stored, err := s.store.ListRobots(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	draining := 0
+	for _, r := range stored {
+		if r.Status == db.RobotStatusDraining {
+			s.fleet.SetDraining(r.ID, true)
+			draining++
+		}
+	}
+	return draining, nil
+}
+
+// stored is nil for robots that connected without being registered
+func robotToProto(id string, stored *db.Robot, live robots.Robot, reading *telemetry.Reading) *pb.Robot {
+	robot := &pb.Robot{
+		RobotId:  id,
+		State:    live.State.String(),
+		Draining: live.Draining,
+	}
+	if !live.Since.IsZero() {
+		robot.StateSince = timestamppb.New(live.Since)
+	}
+	if live.UnhealthyUntil.After(time.Now()) {
+		robot.UnhealthyUntil = timestamppb.New(live.UnhealthyUntil)
+	}
+	if !live.LastHeartbeat.IsZero() {
+		robot.Stale = live.Stale
+		robot.Battery = int32(live.Battery)
+		robot.LastHeartbeat = timestamppb.New(live.LastHeartbeat)
+	}
+	if reading != nil {
+		robot.Position = &pb.RobotPosition{X: int32(reading.Loc.X), Y: int32(reading.Loc.Y)}
+		robot.Speed = reading.Speed
+		robot.Faults = reading.Faults
+		robot.PositionAt = timestamppb.New(reading.At)
+		if reading.Battery > 0 && reading.At.After(live.LastHeartbeat) {
+			robot.Battery = int32(reading.Battery)
+		}
+	}
+	if stored != nil {
+		robot.Registered = true
+		robot.CurrentLocId = stored.CurrentLoc
+		if lastUpdate, err := time.Parse(time.RFC3339Nano, stored.LastUpdate); err == nil {
+			robot.LastUpdate = timestamppb.New(lastUpdate)
+		}
+	}
+	return robot
+}
+
+// the robot's latest telemetry, nil if it hasn't sent any lately
+func (s *FleetServer) reading(robotID string) *telemetry.Reading {
+	if s.live == nil {
+		return nil
+	}
+	if r, ok := s.live.Get(robotID); ok {
+		return &r
+	}
+	return nil
+}
+
+func (s *FleetServer) getRobot(ctx context.Context, robotId string) (*pb.Robot, error) {
+	live, connected := s.fleet.Get(robotId)
+
+	stored, err := s.store.GetRobot(ctx, robotId)
+	if errors.Is(err, db.ErrNotFound) && connected {
+		return robotToProto(robotId, nil, live, s.reading(robotId)), nil
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return robotToProto(robotId, &stored, live, s.reading(robotId)), nil
+}
+
+func (s *FleetServer) ListRobots(ctx context.Context, req *pb.ListRobotsRequest) (*pb.ListRobotsResponse, error) {
+	stored, err := s.store.ListRobots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]robots.Robot)
+	for _, r := range s.fleet.Robots() {
+		live[r.ID] = r
+	}
+
+	resp := &pb.ListRobotsResponse{}
+	for i := range stored {
+		resp.Robots = append(resp.Robots, robotToProto(stored[i].ID, &stored[i], live[stored[i].ID], s.reading(stored[i].ID)))
+		delete(live, stored[i].ID)
+	}
+	for id, r := range live {
+		resp.Robots = append(resp.Robots, robotToProto(id, nil, r, s.reading(id)))
+	}
+	slices.SortFunc(resp.Robots, func(a, b *pb.Robot) int {
+		return strings.Compare(a.GetRobotId(), b.GetRobotId())
+	})
+	return resp, nil
+}
+
+func (s *FleetServer) GetRobot(ctx context.Context, req *pb.GetRobotRequest) (*pb.GetRobotResponse, error) {
+	robot, err := s.getRobot(ctx, req.GetRobotId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetRobotResponse{Robot: robot}
+	for _, t := range s.fleet.History(req.GetRobotId()) {
+		resp.History = append(resp.History, &pb.RobotTransition{
+			From:   t.From.String(),
+			To:     t.To.String(),
+			At:     timestamppb.New(t.At),
+			Reason: t.Reason,
+		})
+	}
+	for _, a := range s.fleet.Attempts(req.GetRobotId()) {
+		attempt := &pb.AssignmentAttempt{
+			At:      timestamppb.New(a.At),
+			Outcome: a.Outcome.String(),
+			Reason:  a.Reason,
+		}
+		for _, orderID := range a.OrderIDs {
+			attempt.OrderIds = append(attempt.OrderIds, int64(orderID))
+		}
+		resp.Attempts = append(resp.Attempts, attempt)
+	}
+	return resp, nil
+}
+
+func (s *FleetServer) DrainRobot(ctx context.Context, req *pb.DrainRobotRequest) (*pb.DrainRobotResponse, error) {
+	robotId := req.GetRobotId()
+	if _, err := s.getRobot(ctx, robotId); err != nil {
+		return nil, err
+	}
+
+	draining := !req.GetResume()
+	robotStatus := db.RobotStatusActive
+	if draining {
+		robotStatus = db.RobotStatusDraining
+	}
+	// written first so a restart never forgets a drain the operator was told about
+	if err := s.store.SetRobotStatus(ctx, robotId, robotStatus); err != nil {
+		return nil, err
+	}
+	s.fleet.SetDraining(robotId, draining)
+
+	robot, err := s.getRobot(ctx, robotId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DrainRobotResponse{Robot: robot}, nil
+}
+
+func (s *FleetServer) RecallRobot(ctx context.Context, req *pb.RecallRobotRequest) (*pb.RecallRobotResponse, error) {
+	robotId := req.GetRobotId()
+	if _, err := s.getRobot(ctx, robotId); err != nil {
+		return nil, err
+	}
+
+	reason := req.GetReason()
+	if reason == "" {
+		reason = "recalled by operator"
+	}
+	requeued, err := s.orm.RecallRobot(robotId, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed recalling robot %s: %w", robotId, err)
+	}
+
+	resp := &pb.RecallRobotResponse{}
+	for _, orderId := range requeued {
+		resp.RequeuedOrderIds = append(resp.RequeuedOrderIds, int64(orderId))
+	}
+	return resp, nil
+}
+
+func (s *FleetServer) ForceUnassign(ctx context.Context, req *pb.ForceUnassignRequest) (*pb.ForceUnassignResponse, error) {
+	orderId := req.GetOrderId()
+
+	reason := req.GetReason()
+	if reason == "" {
+		reason = "unassigned by operator"
+	}
+	unassigned, err := s.orm.UnassignOrder(int(orderId), reason)
+	if errors.Is(err, matcher.ErrOrderPickedUp) {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed unassigning order %d: %w", orderId, err)
+	}
+	if !unassigned {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d isn't out with a robot", orderId)
+	}
+
+	return &pb.ForceUnassignResponse{ReturnMsg: "SUCCESS"}, nil
+}
+
+func (s *FleetServer) RegisterRobot(ctx context.Context, req *pb.RegisterRobotRequest) (*pb.RegisterRobotResponse, error) {
+	inserted, err := s.store.InsertRobot(ctx, db.Robot{
+		ID:     req.GetRobotId(), // left out when empty, the database picks one
+		Status: db.RobotStatusActive,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	token, expires, err := s.auth.Issue(inserted.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed issuing token for robot %s: %w", inserted.ID, err)
+	}
+
+	live, _ := s.fleet.Get(inserted.ID)
+	return &pb.RegisterRobotResponse{
+		Robot:          robotToProto(inserted.ID, &inserted, live, s.reading(inserted.ID)),
+		Token:          token,
+		TokenExpiresAt: timestamppb.New(expires),
+	}, nil
+}
+
+func (s *FleetServer) IssueRobotToken(ctx context.Context, req *pb.IssueRobotTokenRequest) (*pb.IssueRobotTokenResponse, error) {
+	robotId := req.GetRobotId()
+	if _, err := s.store.GetRobot(ctx, robotId); err != nil { // only registered robots can connect anyway
+		return nil, grpcError(err)
+	}
+
+	token, expires, err := s.auth.Issue(robotId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.IssueRobotTokenResponse{Token: token, TokenExpiresAt: timestamppb.New(expires)}, nil
+}
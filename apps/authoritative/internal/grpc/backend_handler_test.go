@@ -0,0 +1,535 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/routing"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/proto"
+)
+
+// a server over an in-memory store with one vendor at (10, 10) and one drop-off at (20, 20)
+func newTestServer(t *testing.T) (*OrderServer, *db.MemoryStore) {
+	orders, _, _, store := newTestServers(t)
+	return orders, store
+}
+
+// the order and fleet servers sharing one matcher and fleet, like main sets them up
+func newTestServers(t *testing.T) (*OrderServer, *FleetServer, *robots.Manager, *db.MemoryStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	store := db.NewMemoryStore()
+	store.InsertCoordinate(ctx, db.Coordinate{ID: "vendor-loc", X: 10, Y: 10, Type: db.CoordinateTypeVendor})
+	store.InsertCoordinate(ctx, db.Coordinate{ID: "dropoff-loc", X: 20, Y: 20, Type: db.CoordinateTypeDropoff})
+	store.InsertVendor(ctx, db.Vendor{ID: "vendor-1", Name: "Cafe", Coordinates: "vendor-loc"})
+
+	fleet := robots.NewManager()
+	states := state.NewManager(store)
+	orm := matcher.CreateOrderRobotMatcher(
+		matcher.WithRobots(fleet),
+		matcher.WithStore(matcher.NewDBOrderStore(store, states)),
+	)
+	engineCtx, cancel := context.WithCancel(context.Background())
+	orm.StartORM(engineCtx)
+	t.Cleanup(func() {
+		cancel()
+		orm.Wait()
+	})
+
+	auth, err := security.NewRobotAuth([]byte("test-secret-that-is-long-enough-for-hmac"), store)
+	if err != nil {
+		t.Fatalf("unexpected error setting up auth: %v", err)
+	}
+
+	return NewOrderServer(store, orm, states), NewFleetServer(store, fleet, orm, auth), fleet, store
+}
+
+func asUser(userID string) context.Context {
+	return WithIdentity(context.Background(), Identity{UserID: userID})
+}
+
+func asService() context.Context {
+	return WithIdentity(context.Background(), Identity{ServiceRole: true})
+}
+
+func testOrder() *pb.Order {
+	return &pb.Order{
+		UserId:       "user-1",
+		VendorId:     "vendor-1",
+		DropoffLocId: "dropoff-loc",
+		Items: []*pb.OrderItem{
+			{ItemName: "coffee", Quantity: 2, Price: 3.5},
+			{ItemName: "bagel", Quantity: 1, Price: 2},
+		},
+	}
+}
+
+func waitForStatus(t *testing.T, store *db.MemoryStore, orderID int64, status string) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		o, err := store.GetOrder(context.Background(), orderID)
+		if err == nil && o.Status == status {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("order %d never reached status %q, last %q (%v)", orderID, status, o.Status, err)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func waitForRobot(t *testing.T, store *db.MemoryStore, orderID int64, robotID string) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		o, err := store.GetOrder(context.Background(), orderID)
+		if err == nil && o.RobotID == robotID {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("order %d never had robot %q, last %q (%v)", orderID, robotID, o.RobotID, err)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestInsertOrderWritesOrderAndItems(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asUser("user-1")
+
+	resp, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID := resp.GetOrder().GetOrderId()
+	if orderID == 0 {
+		t.Fatal("expected the response to carry the new order id")
+	}
+
+	items, _ := store.GetOrderItems(ctx, orderID)
+	if len(items) != 2 {
+		t.Errorf("expected 2 items stored, got %d", len(items))
+	}
+
+	// no robots around, the matcher queues it
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+}
+
+func TestInsertOrderReplaysIdempotencyKey(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asUser("user-1")
+
+	first, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder(), IdempotencyKey: "retry-me"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the retry carries the key as metadata instead
+	retryCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("idempotency-key", "retry-me"))
+	retry, err := s.InsertOrder(retryCtx, &pb.InsertOrderRequest{Order: testOrder()})
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if retry.GetOrder().GetOrderId() != first.GetOrder().GetOrderId() {
+		t.Errorf("expected the retry to get order %d back, got %d", first.GetOrder().GetOrderId(), retry.GetOrder().GetOrderId())
+	}
+	if len(retry.GetOrder().GetItems()) != 2 {
+		t.Errorf("expected the replay to carry the items, got %d", len(retry.GetOrder().GetItems()))
+	}
+	if orders, _ := store.ListOrdersByUser(ctx, "user-1", db.ListOptions{}); len(orders) != 1 {
+		t.Errorf("expected one order stored, got %d", len(orders))
+	}
+
+	other := testOrder()
+	other.DropoffLocId = "vendor-loc"
+	if _, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: other, IdempotencyKey: "retry-me"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument reusing a key for another order, got %v", err)
+	}
+
+	// another user's key space is their own
+	if resp, err := s.InsertOrder(asService(), &pb.InsertOrderRequest{Order: &pb.Order{UserId: "user-2", VendorId: "vendor-1", DropoffLocId: "dropoff-loc"}, IdempotencyKey: "retry-me"}); err != nil || resp.GetOrder().GetOrderId() == first.GetOrder().GetOrderId() {
+		t.Errorf("expected user-2 to get a new order with the same key, got %v (%v)", resp.GetOrder(), err)
+	}
+}
+
+func TestInsertOrderKeyExpiresAfterWindow(t *testing.T) {
+	s, store := newTestServer(t)
+	s.idempotencyWindow = 0 // every earlier order is too old
+	ctx := asUser("user-1")
+
+	first, _ := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder(), IdempotencyKey: "reused"})
+	second, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder(), IdempotencyKey: "reused"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.GetOrder().GetOrderId() == first.GetOrder().GetOrderId() {
+		t.Error("expected a new order once the window has passed")
+	}
+	if o, _ := store.GetOrderByIdempotencyKey(ctx, "user-1", "reused"); o.ID != second.GetOrder().GetOrderId() {
+		t.Errorf("expected the key to move to the new order, got order %d", o.ID)
+	}
+}
+
+func TestInsertOrderUnknownVendorWritesNothing(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asUser("user-1")
+
+	order := testOrder()
+	order.VendorId = "nope"
+	if _, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: order}); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if orders, _ := store.ListOrdersByUser(ctx, "user-1", db.ListOptions{}); len(orders) != 0 {
+		t.Errorf("expected no orders written, got %d", len(orders))
+	}
+}
+
+func TestDeleteOrderRemovesQueuedOrder(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asUser("user-1")
+
+	resp, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID := resp.GetOrder().GetOrderId()
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+
+	if _, err := s.DeleteOrder(ctx, &pb.DeleteOrderRequest{Order: &pb.Order{OrderId: orderID}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.GetOrder(ctx, orderID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected the order to be gone, got %v", err)
+	}
+	if items, _ := store.GetOrderItems(ctx, orderID); len(items) != 0 {
+		t.Errorf("expected the items to be gone, got %d", len(items))
+	}
+}
+
+func TestGetOrderReturnsItemsAndStatus(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asUser("user-1")
+
+	resp, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID := resp.GetOrder().GetOrderId()
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+
+	got, err := s.GetOrder(ctx, &pb.GetOrderRequest{OrderId: orderID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.GetOrder().GetStatus() != "queued" {
+		t.Errorf("expected status queued, got %s", got.GetOrder().GetStatus())
+	}
+	if len(got.GetOrder().GetItems()) != 2 {
+		t.Errorf("expected 2 items, got %d", len(got.GetOrder().GetItems()))
+	}
+
+	if _, err := s.GetOrder(ctx, &pb.GetOrderRequest{OrderId: 999}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a missing order, got %v", err)
+	}
+}
+
+func TestListOrdersByUserPages(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := asUser("user-1")
+
+	for range 5 {
+		if _, err := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var seen []int64
+	token := ""
+	for page := 0; ; page++ {
+		resp, err := s.ListOrdersByUser(ctx, &pb.ListOrdersByUserRequest{UserId: "user-1", PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, o := range resp.GetOrders() {
+			seen = append(seen, o.GetOrderId())
+		}
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+		if page > 5 {
+			t.Fatal("pagination never ended")
+		}
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 orders across pages, got %v", seen)
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] <= seen[i-1] {
+			t.Errorf("expected orders oldest first without repeats, got %v", seen)
+		}
+	}
+}
+
+func TestListOrdersByVendorFiltersByStatus(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asService()
+
+	first, _ := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	second, _ := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	waitForStatus(t, store, second.GetOrder().GetOrderId(), db.OrderStatusQueued)
+
+	if _, err := s.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{OrderId: first.GetOrder().GetOrderId(), Status: "cancelled"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := s.ListOrdersByVendor(ctx, &pb.ListOrdersByVendorRequest{
+		VendorId: "vendor-1",
+		Filter:   &pb.OrderFilter{Statuses: []string{"queued"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.GetOrders()) != 1 || resp.GetOrders()[0].GetOrderId() != second.GetOrder().GetOrderId() {
+		t.Errorf("expected only the queued order, got %v", resp.GetOrders())
+	}
+
+	if _, err := s.ListOrdersByVendor(ctx, &pb.ListOrdersByVendorRequest{VendorId: "vendor-1", PageToken: "abc"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad page token, got %v", err)
+	}
+}
+
+func TestUpdateOrderStatusRejectsIllegalMoves(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := asService()
+
+	resp, _ := s.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	orderID := resp.GetOrder().GetOrderId()
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+
+	// a queued order can't be delivered before a robot has it
+	_, err := s.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{OrderId: orderID, Status: "delivered"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+
+	_, err = s.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{OrderId: orderID, Status: "matched"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a matcher-only status, got %v", err)
+	}
+}
+
+func TestListRobotsMergesRegisteredAndConnected(t *testing.T) {
+	_, admin, fleet, _ := newTestServers(t)
+	ctx := context.Background()
+
+	registered, err := admin.RegisterRobot(ctx, &pb.RegisterRobotRequest{RobotId: "robot-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if robotID, err := admin.auth.Verify(registered.GetToken()); err != nil || robotID != "robot-1" {
+		t.Errorf("expected a token for robot-1, got %q (%v)", robotID, err)
+	}
+	if _, err := admin.RegisterRobot(ctx, &pb.RegisterRobotRequest{RobotId: "robot-1"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists registering twice, got %v", err)
+	}
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	fleet.Transition("robot-2", robots.StateIdle, "test") // connected without being registered
+
+	resp, err := admin.ListRobots(ctx, &pb.ListRobotsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := resp.GetRobots()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 robots, got %d", len(got))
+	}
+	if got[0].GetRobotId() != "robot-1" || !got[0].GetRegistered() || got[0].GetState() != "idle" {
+		t.Errorf("expected registered, idle robot-1, got %+v", got[0])
+	}
+	if got[1].GetRobotId() != "robot-2" || got[1].GetRegistered() {
+		t.Errorf("expected unregistered robot-2, got %+v", got[1])
+	}
+
+	if _, err := admin.GetRobot(ctx, &pb.GetRobotRequest{RobotId: "robot-3"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a robot nobody has seen, got %v", err)
+	}
+
+	fleet.RecordAttempt("robot-2", []int{7}, robots.AttemptRejected, "busy")
+	fleet.MarkUnhealthy("robot-2", time.Now().Add(time.Minute))
+	robot2, err := admin.GetRobot(ctx, &pb.GetRobotRequest{RobotId: "robot-2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if robot2.GetRobot().GetUnhealthyUntil() == nil {
+		t.Error("expected robot-2 to show up as unhealthy")
+	}
+	if attempts := robot2.GetAttempts(); len(attempts) != 1 || attempts[0].GetOutcome() != "rejected" || attempts[0].GetOrderIds()[0] != 7 {
+		t.Errorf("expected the rejected attempt, got %+v", attempts)
+	}
+}
+
+func TestLiveTelemetryShowsUpInTheAPI(t *testing.T) {
+	_, _, fleet, store := newTestServers(t)
+	ctx := context.Background()
+	live := telemetry.NewStore()
+	admin := NewFleetServer(store, fleet, nil, nil, WithLiveStore(live))
+	orders := NewOrderServer(store, nil, state.NewManager(store), WithEstimator(routing.NewEstimator(live)))
+
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	fleet.Heartbeat("robot-1", 90)
+	live.Record(telemetry.Reading{RobotID: "robot-1", Loc: geo.Point{X: 10, Y: 0}, Battery: 70, Speed: 1, Faults: []string{"lidar"}})
+
+	resp, err := admin.GetRobot(ctx, &pb.GetRobotRequest{RobotId: "robot-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	robot := resp.GetRobot()
+	if robot.GetPosition().GetX() != 10 || robot.GetPosition().GetY() != 0 || robot.GetPositionAt() == nil {
+		t.Errorf("expected the live position, got %+v", robot.GetPosition())
+	}
+	if robot.GetSpeed() != 1 || len(robot.GetFaults()) != 1 || robot.GetFaults()[0] != "lidar" {
+		t.Errorf("expected the live vitals, got %+v", robot)
+	}
+	if robot.GetBattery() != 70 {
+		t.Errorf("expected the newer telemetry battery over the heartbeat's, got %d", robot.GetBattery())
+	}
+	if list, _ := admin.ListRobots(ctx, &pb.ListRobotsRequest{}); list.GetRobots()[0].GetPosition() == nil {
+		t.Error("expected listed robots to carry their position too")
+	}
+
+	// 10 to the vendor at (10, 10), then about 14.1 on to the drop-off at (20, 20)
+	matched, _ := store.CreateOrder(ctx, db.Order{UserID: "user-1", VendorID: "vendor-1", DropOffLocation: "dropoff-loc", Status: db.OrderStatusMatched, RobotID: "robot-1"})
+	got, err := orders.GetOrder(asUser("user-1"), &pb.GetOrderRequest{OrderId: matched.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.GetEtaSeconds() != 24 {
+		t.Errorf("expected a 24s eta by way of the vendor, got %d", got.GetEtaSeconds())
+	}
+
+	live.Record(telemetry.Reading{RobotID: "robot-1", Loc: geo.Point{X: 20, Y: 10}, Speed: 2})
+	inTransit, _ := store.CreateOrder(ctx, db.Order{UserID: "user-1", VendorID: "vendor-1", DropOffLocation: "dropoff-loc", Status: db.OrderStatusInTransit, RobotID: "robot-1"})
+	if got, _ := orders.GetOrder(asUser("user-1"), &pb.GetOrderRequest{OrderId: inTransit.ID}); got.GetEtaSeconds() != 5 {
+		t.Errorf("expected a 5s eta straight to the drop-off, got %d", got.GetEtaSeconds())
+	}
+
+	queued, _ := store.CreateOrder(ctx, db.Order{UserID: "user-1", VendorID: "vendor-1", DropOffLocation: "dropoff-loc", Status: db.OrderStatusQueued})
+	if got, _ := orders.GetOrder(asUser("user-1"), &pb.GetOrderRequest{OrderId: queued.ID}); got.GetEtaSeconds() != 0 {
+		t.Errorf("expected no eta without a robot, got %d", got.GetEtaSeconds())
+	}
+}
+
+func TestDrainRobotIsPersistedAndRestored(t *testing.T) {
+	_, admin, fleet, store := newTestServers(t)
+	ctx := context.Background()
+
+	admin.RegisterRobot(ctx, &pb.RegisterRobotRequest{RobotId: "robot-1"})
+	resp, err := admin.DrainRobot(ctx, &pb.DrainRobotRequest{RobotId: "robot-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.GetRobot().GetDraining() || !fleet.Draining("robot-1") {
+		t.Error("expected robot-1 to be draining")
+	}
+	if r, _ := store.GetRobot(ctx, "robot-1"); r.Status != db.RobotStatusDraining {
+		t.Errorf("expected the drain to be written to the store, got status %d", r.Status)
+	}
+
+	// a fresh fleet after a restart
+	restarted := NewFleetServer(store, robots.NewManager(), nil, nil)
+	if n, err := restarted.Restore(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 draining robot restored, got %d (%v)", n, err)
+	}
+	if !restarted.fleet.Draining("robot-1") {
+		t.Error("expected the drain to survive a restart")
+	}
+
+	admin.DrainRobot(ctx, &pb.DrainRobotRequest{RobotId: "robot-1", Resume: true})
+	if fleet.Draining("robot-1") {
+		t.Error("expected robot-1 to take routes again after resuming")
+	}
+}
+
+func TestRecallRobotRequeuesItsOrder(t *testing.T) {
+	orders, admin, fleet, store := newTestServers(t)
+	ctx := asService()
+
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	orders.orm.SubmitRobot(matcher.NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+
+	resp, _ := orders.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	orderID := resp.GetOrder().GetOrderId()
+	waitForStatus(t, store, orderID, db.OrderStatusMatched)
+
+	// draining first keeps the robot from getting the order straight back
+	if _, err := admin.DrainRobot(ctx, &pb.DrainRobotRequest{RobotId: "robot-1"}); err != nil {
+		t.Fatalf("unexpected error draining: %v", err)
+	}
+	recalled, err := admin.RecallRobot(ctx, &pb.RecallRobotRequest{RobotId: "robot-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := recalled.GetRequeuedOrderIds(); len(ids) != 1 || ids[0] != orderID {
+		t.Errorf("expected order %d to be requeued, got %v", orderID, ids)
+	}
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+
+	if _, err := admin.ForceUnassign(ctx, &pb.ForceUnassignRequest{OrderId: orderID}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition unassigning a queued order, got %v", err)
+	}
+
+	// back in the queue the order no longer points at the robot it lost
+	waitForRobot(t, store, orderID, "")
+}
+
+func TestRestoreRequeuesMatchedOrders(t *testing.T) {
+	orders, _, fleet, store := newTestServers(t)
+	ctx := asService()
+
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	orders.orm.SubmitRobot(matcher.NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+
+	resp, _ := orders.InsertOrder(ctx, &pb.InsertOrderRequest{Order: testOrder()})
+	orderID := resp.GetOrder().GetOrderId()
+	waitForStatus(t, store, orderID, db.OrderStatusMatched)
+	waitForRobot(t, store, orderID, "robot-1")
+
+	// a fresh matcher after a restart has no idea robot-1 ever had the order
+	restarted := matcher.CreateOrderRobotMatcher(
+		matcher.WithRobots(robots.NewManager()),
+		matcher.WithStore(matcher.NewDBOrderStore(store, state.NewManager(store))),
+	)
+	engineCtx, cancel := context.WithCancel(context.Background())
+	restarted.StartORM(engineCtx)
+	defer func() {
+		cancel()
+		restarted.Wait()
+	}()
+
+	if n, err := restarted.Restore(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected 1 order restored, got %d (%v)", n, err)
+	}
+	waitForStatus(t, store, orderID, db.OrderStatusQueued)
+	waitForRobot(t, store, orderID, "")
+}
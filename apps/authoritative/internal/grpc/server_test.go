@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/proto"
+)
+
+var testJWTSecret = []byte("super-secret-jwt-token-for-tests")
+
+func signToken(t *testing.T, secret []byte, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed signing token: %v", err)
+	}
+	return token
+}
+
+func userToken(t *testing.T, userID string) string {
+	return signToken(t, testJWTSecret, jwt.MapClaims{
+		"sub":  userID,
+		"role": "authenticated",
+		"aud":  "authenticated",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func serviceToken(t *testing.T) string {
+	return signToken(t, testJWTSecret, jwt.MapClaims{"role": "service_role"})
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestUnaryInterceptorChecksTokens(t *testing.T) {
+	auth, err := NewAuthenticator(testJWTSecret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	interceptor := auth.UnaryInterceptor()
+
+	var seen Identity
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen, _ = IdentityFrom(ctx)
+		return nil, nil
+	}
+	call := func(ctx context.Context, method string) error {
+		seen = Identity{}
+		_, err := interceptor(ctx, nil, &grpclib.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	getOrder := "/order_service.OrderHandler/GetOrder"
+	listRobots := "/order_service.FleetAdmin/ListRobots"
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"no metadata", context.Background(), codes.Unauthenticated},
+		{"not a bearer token", metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic abc")), codes.Unauthenticated},
+		{"wrong secret", withToken(signToken(t, []byte("someone-elses-secret"), jwt.MapClaims{"sub": "user-1", "role": "authenticated", "exp": time.Now().Add(time.Hour).Unix()})), codes.Unauthenticated},
+		{"expired", withToken(signToken(t, testJWTSecret, jwt.MapClaims{"sub": "user-1", "role": "authenticated", "exp": time.Now().Add(-time.Minute).Unix()})), codes.Unauthenticated},
+		{"anon key", withToken(signToken(t, testJWTSecret, jwt.MapClaims{"role": "anon"})), codes.Unauthenticated},
+		{"user without expiry", withToken(signToken(t, testJWTSecret, jwt.MapClaims{"sub": "user-1", "role": "authenticated"})), codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		if err := call(tt.ctx, getOrder); status.Code(err) != tt.code {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.code, err)
+		}
+	}
+
+	if err := call(withToken(userToken(t, "user-1")), getOrder); err != nil || seen.UserID != "user-1" {
+		t.Errorf("expected the handler to see user-1, got %+v (%v)", seen, err)
+	}
+	if err := call(withToken(userToken(t, "user-1")), listRobots); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected users to be kept out of FleetAdmin, got %v", err)
+	}
+	if err := call(withToken(serviceToken(t)), listRobots); err != nil || !seen.ServiceRole {
+		t.Errorf("expected the service role to get into FleetAdmin, got %+v (%v)", seen, err)
+	}
+}
+
+func TestServerEnforcesOwnershipEndToEnd(t *testing.T) {
+	orders, _, _, _ := newTestServers(t)
+	auth, _ := NewAuthenticator(testJWTSecret)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(auth)
+	pb.RegisterOrderHandlerServer(srv, orders)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpclib.NewClient("passthrough:///bufnet",
+		grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpclib.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed dialing: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := pb.NewOrderHandlerClient(conn)
+
+	as := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	// no user id in the body, it comes from the token
+	order := testOrder()
+	order.UserId = ""
+	resp, err := client.InsertOrder(as(userToken(t, "user-1")), &pb.InsertOrderRequest{Order: order})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID := resp.GetOrder().GetOrderId()
+	if resp.GetOrder().GetUserId() != "user-1" {
+		t.Errorf("expected the order to belong to user-1, got %q", resp.GetOrder().GetUserId())
+	}
+
+	if _, err := client.GetOrder(as(userToken(t, "user-2")), &pb.GetOrderRequest{OrderId: orderID}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected user-2 to be kept from reading user-1's order, got %v", err)
+	}
+	if _, err := client.DeleteOrder(as(userToken(t, "user-2")), &pb.DeleteOrderRequest{Order: &pb.Order{OrderId: orderID}}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected user-2 to be kept from deleting user-1's order, got %v", err)
+	}
+	if _, err := client.InsertOrder(as(userToken(t, "user-2")), &pb.InsertOrderRequest{Order: testOrder()}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected user-2 to be kept from ordering as user-1, got %v", err)
+	}
+
+	stream, err := client.WatchOrder(context.Background(), &pb.WatchOrderRequest{OrderId: orderID})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected watching without a token to be refused, got %v", err)
+	}
+
+	if _, err := client.GetOrder(as(serviceToken(t)), &pb.GetOrderRequest{OrderId: orderID}); err != nil {
+		t.Errorf("expected the service role to read any order, got %v", err)
+	}
+	if _, err := client.DeleteOrder(as(userToken(t, "user-1")), &pb.DeleteOrderRequest{Order: &pb.Order{OrderId: orderID}}); err != nil {
+		t.Errorf("expected user-1 to delete their own order, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package matcher
+
+// batching several orders onto one robot when it has the compartments for it
+import (
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// default for how far apart two drop-offs can be and still share a trip, in grid units
+const DefaultBatchRadius = 15.0
+
+// one order on a robot's trip
+type RouteStop struct {
+	OrderID     int
+	Compartment int
+	Dropoff     geo.Point
+}
+
+// whether extra can ride along with lead: same pickup and a drop-off close to the lead's
+func (orm *OrderRobotMatcher) canBatch(lead *OrderItem, extra *OrderItem) bool {
+	return extra.vendorLoc == lead.vendorLoc && geo.Distance(extra.dropoffLoc, lead.dropoffLoc) <= orm.batchRadius
+}
+
+// picks orders to ride along with lead until the robot is full, in priority order
+func (orm *OrderRobotMatcher) fillCompartments(lead *OrderItem, robot RobotItem, orders []*OrderItem, routed map[*OrderItem]bool) []*OrderItem {
+	batch := []*OrderItem{lead}
+	for _, o := range orders {
+		if len(batch) >= robot.Capacity() {
+			break
+		}
+		if o == lead || routed[o] || !orm.canBatch(lead, o) {
+			continue
+		}
+		batch = append(batch, o)
+	}
+	return batch
+}
+
+// orders the drop-offs nearest first from the vendor and hands out compartments in loading order
+func buildRoute(batch []*OrderItem, robot RobotItem) []RouteStop {
+	firstFree := 0
+	if robot.compartments > 0 {
+		firstFree = robot.compartments - robot.free
+	}
+
+	remaining := append([]*OrderItem(nil), batch...)
+	at := batch[0].vendorLoc
+	route := make([]RouteStop, 0, len(batch))
+	for len(remaining) > 0 {
+		next := 0
+		for i, o := range remaining {
+			if geo.Distance(at, o.dropoffLoc) < geo.Distance(at, remaining[next].dropoffLoc) {
+				next = i
+			}
+		}
+
+		o := remaining[next]
+		route = append(route, RouteStop{
+			OrderID:     o.orderId,
+			Compartment: firstFree + len(route),
+			Dropoff:     o.dropoffLoc,
+		})
+		at = o.dropoffLoc
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return route
+}
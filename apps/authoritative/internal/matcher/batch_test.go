@@ -0,0 +1,150 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func dropoffOrder(orderId int, vendor geo.Point, dropoff geo.Point) *OrderItem {
+	return CreateOrder("user", orderId, orderId, vendor, dropoff, PriorityStandard)
+}
+
+func TestAttemptMatchBatchesSameVendorOrders(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 40, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 30, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(3, vendor, geo.Point{X: 45, Y: 5}))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1", compartments: 4, free: 3})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	if len(matchesChan) != 1 {
+		t.Fatalf("expected one route, got %d", len(matchesChan))
+	}
+	m := <-matchesChan
+	if m.OrderID != 1 {
+		t.Errorf("expected the route to lead with order 1, got %d", m.OrderID)
+	}
+
+	// nearest drop-off first, compartments handed out from the first free one
+	expected := []RouteStop{
+		{OrderID: 2, Compartment: 1, Dropoff: geo.Point{X: 30, Y: 0}},
+		{OrderID: 1, Compartment: 2, Dropoff: geo.Point{X: 40, Y: 0}},
+		{OrderID: 3, Compartment: 3, Dropoff: geo.Point{X: 45, Y: 5}},
+	}
+	if len(m.Route) != len(expected) {
+		t.Fatalf("expected %d stops, got %d", len(expected), len(m.Route))
+	}
+	for i, stop := range expected {
+		if m.Route[i] != stop {
+			t.Errorf("stop %d: expected %+v, got %+v", i, stop, m.Route[i])
+		}
+	}
+
+	if orm.orderQueue.Len() != 0 {
+		t.Errorf("expected every order routed, %d still queued", orm.orderQueue.Len())
+	}
+	for _, orderID := range []int{1, 2, 3} {
+		if orm.active[orderID].robot.robotID != "robot-1" {
+			t.Errorf("expected order %d to be out with robot-1", orderID)
+		}
+	}
+}
+
+func TestAttemptMatchOnlyBatchesCloseDropoffs(t *testing.T) {
+	orm := CreateOrderRobotMatcher(WithBatchRadius(10))
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 20, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 80, Y: 0}))                // too far from order 1
+	orm.orderQueue.Insert(dropoffOrder(3, geo.Point{X: 5, Y: 5}, geo.Point{X: 21, Y: 0})) // different vendor
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1", compartments: 3, free: 3})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	m := <-matchesChan
+	if len(m.Route) != 1 || m.Route[0].OrderID != 1 {
+		t.Errorf("expected a route with only order 1, got %+v", m.Route)
+	}
+	if orm.orderQueue.Len() != 2 {
+		t.Errorf("expected 2 orders left waiting, got %d", orm.orderQueue.Len())
+	}
+}
+
+func TestAttemptMatchBatchingLeavesOtherRobotsIdle(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 10, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 12, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(3, geo.Point{X: 90, Y: 90}, geo.Point{X: 95, Y: 90}))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-big", compartments: 2, free: 2})
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-small", loc: geo.Point{X: 1, Y: 1}})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	if len(matchesChan) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(matchesChan))
+	}
+	routes := map[string]*OrderRobotMatch{}
+	for range 2 {
+		m := <-matchesChan
+		routes[m.RobotID] = m
+	}
+
+	if big := routes["robot-big"]; big == nil || len(big.Route) != 2 {
+		t.Errorf("expected robot-big to carry both orders from the shared vendor, got %+v", big)
+	}
+	// the small robot was bumped off order 2 and picks up the far order on the next pass
+	if small := routes["robot-small"]; small == nil || small.OrderID != 3 {
+		t.Errorf("expected robot-small to take order 3, got %+v", small)
+	}
+}
+
+func TestFullRobotIsNotIdle(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+
+	orm.applyRobotUpdate(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}).WithCapacity(2, 0))
+	if orm.robotQueue.Has("robot-1") {
+		t.Error("expected a robot with no free compartments to stay out of the queue")
+	}
+
+	orm.applyRobotUpdate(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}).WithCapacity(2, 1))
+	if !orm.robotQueue.Has("robot-1") {
+		t.Error("expected the robot to be queued once a compartment frees up")
+	}
+}
+
+func TestCancelOneBatchedOrderKeepsRobotBusy(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 10, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 12, Y: 0}))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1", compartments: 2, free: 2})
+	orm.attemptMatch(context.Background(), matchesChan)
+	<-matchesChan
+
+	req := &cancelRequest{orderID: 2}
+	if got := orm.applyCancel(context.Background(), req, matchesChan); got != CancelRecalled {
+		t.Fatalf("expected CancelRecalled, got %v", got)
+	}
+	if orm.robotQueue.Has("robot-1") {
+		t.Error("expected robot-1 to stay busy with order 1")
+	}
+
+	req = &cancelRequest{orderID: 1}
+	orm.applyCancel(context.Background(), req, matchesChan)
+	if !orm.robotQueue.Has("robot-1") {
+		t.Error("expected robot-1 back in the queue once its whole route is cancelled")
+	}
+}
@@ -0,0 +1,105 @@
+package matcher
+
+// cancelling orders, whether they are still waiting in the queue or already out with a robot
+import (
+	"context"
+	"log"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+)
+
+type CancelResult int
+
+const (
+	CancelNotFound CancelResult = iota // never queued, already delivered, or cancelled before
+	CancelDequeued                     // was still waiting on a robot
+	CancelRecalled                     // was out with a robot, which has been recalled
+)
+
+type cancelRequest struct {
+	orderID int
+	result  chan CancelResult
+}
+
+// pulls the order out of the matcher, if a robot already has it the robot gets recalled and goes back to idle
+func (orm *OrderRobotMatcher) CancelOrder(orderID int) (CancelResult, error) {
+	req := &cancelRequest{orderID: orderID, result: make(chan CancelResult, 1)}
+
+	select {
+	case orm.cancelIntake <- req:
+	case <-orm.done:
+		return CancelNotFound, ErrMatcherStopped
+	}
+
+	select {
+	case result := <-req.result:
+		return result, nil
+	case <-orm.done:
+		return CancelNotFound, ErrMatcherStopped
+	}
+}
+
+func (orm *OrderRobotMatcher) applyCancel(ctx context.Context, req *cancelRequest, matchesChan chan (*OrderRobotMatch)) CancelResult {
+	if o := orm.orderQueue.Remove(req.orderID); o != nil {
+		orm.record(orderEvent{kind: orderCancelled, orderID: req.orderID})
+		log.Printf("order %d cancelled while queued\n", req.orderID)
+		return CancelDequeued
+	}
+
+	a, ok := orm.active[req.orderID]
+	if !ok {
+		return CancelNotFound
+	}
+
+	// goes out on the same channel as matches so the robot sees the recall before any new assignment
+	select {
+	case matchesChan <- &OrderRobotMatch{OrderID: req.orderID, RobotID: a.robot.robotID, Recall: true, Reason: "order cancelled"}:
+	case <-ctx.Done():
+		return CancelNotFound
+	}
+
+	delete(orm.active, req.orderID)
+	orm.record(orderEvent{kind: orderCancelled, orderID: req.orderID})
+	if !orm.carrying(a.robot.robotID) && orm.recallRobot(a.robot.robotID, "order cancelled") && !orm.benched(a.robot.robotID) { // still out with the rest of its batch otherwise
+		if err := orm.robotQueue.Enqueue(a.robot); err != nil {
+			log.Println(err.Error())
+		}
+	}
+	log.Printf("order %d cancelled, robot %s recalled\n", req.orderID, a.robot.robotID)
+	return CancelRecalled
+}
+
+// sends the robot back to idle, or returning if it already left, reports whether it can take a new route
+func (orm *OrderRobotMatcher) recallRobot(robotID string, reason string) bool {
+	if orm.fleet == nil {
+		return true
+	}
+
+	to := robots.StateReturning
+	if orm.fleet.State(robotID) == robots.StateAssigned { // hasn't moved yet
+		to = robots.StateIdle
+	}
+	if err := orm.fleet.Transition(robotID, to, reason); err != nil {
+		log.Println(err.Error())
+		return false
+	}
+	return true
+}
+
+func (orm *OrderRobotMatcher) carrying(robotID string) bool {
+	for _, a := range orm.active {
+		if a.robot.robotID == robotID {
+			return true
+		}
+	}
+	return false
+}
+
+// a robot reporting in as online is done with whatever it was carrying
+func (orm *OrderRobotMatcher) releaseRobot(robotID string) {
+	for orderID, a := range orm.active {
+		if a.robot.robotID == robotID {
+			delete(orm.active, orderID)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func TestCancelQueuedOrder(t *testing.T) {
+	store := &fakeOrderStore{}
+	orm := CreateOrderRobotMatcher(WithStore(store))
+	ctx, cancel := context.WithCancel(context.Background())
+	matchesChan := orm.StartORM(ctx)
+
+	orm.SubmitOrder(CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+
+	result, err := orm.CancelOrder(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != CancelDequeued {
+		t.Errorf("expected CancelDequeued, got %d", result)
+	}
+
+	// the cancelled order must never reach a robot
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	select {
+	case match := <-matchesChan:
+		t.Errorf("expected no match, got order %d", match.OrderID)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if result, _ := orm.CancelOrder(1); result != CancelNotFound {
+		t.Errorf("expected CancelNotFound on a second cancel, got %d", result)
+	}
+
+	cancel()
+	orm.Wait()
+	if len(store.log) != 2 || store.log[1] != "cancelled 1" {
+		t.Errorf("expected the cancel to be persisted, got %v", store.log)
+	}
+}
+
+func TestCancelMatchedOrderRecallsRobot(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
+
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	orm.SubmitOrder(CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+
+	select {
+	case <-matchesChan:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match before cancelling")
+	}
+
+	result, err := orm.CancelOrder(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != CancelRecalled {
+		t.Errorf("expected CancelRecalled, got %d", result)
+	}
+
+	select {
+	case recall := <-matchesChan:
+		if !recall.Recall || recall.OrderID != 1 || recall.RobotID != "robot-1" {
+			t.Errorf("expected a recall of order 1 from robot-1, got %+v", recall)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a recall to be sent")
+	}
+
+	// the recalled robot is idle again and picks up the next order
+	orm.SubmitOrder(CreateOrder("user", 2, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+	select {
+	case match := <-matchesChan:
+		if match.Recall || match.OrderID != 2 || match.RobotID != "robot-1" {
+			t.Errorf("expected order 2 matched to robot-1, got %+v", match)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the recalled robot to be matched again")
+	}
+}
+
+func TestCancelAfterShutdown(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	orm.StartORM(ctx)
+	cancel()
+	orm.Wait()
+
+	if _, err := orm.CancelOrder(1); err != ErrMatcherStopped {
+		t.Errorf("expected ErrMatcherStopped, got %v", err)
+	}
+}
@@ -1,97 +1,540 @@
 package matcher
 
-// this is the engine for our matching making service, in which when a robot becomes avaialbnle it will send an update, and every second this match maker will attempt to match a user and robot
+// this is the engine for our matching making service, in which when a robot becomes avaialbnle it will send an update, and whenever an order or robot arrives (or every second as a fallback) this match maker will match every order it can
 // orders will come in from grpc request, and
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
 	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
 )
 
 // matcher for orders and robots
 
+var ErrMatcherStopped = errors.New("matcher has been stopped")
+
 type OrderRobotMatch struct {
 	OrderID int
 	RobotID string
+	Recall  bool        // the order was cancelled or taken off the robot, it should drop it and is idle again
+	Reason  string      // why a recall was sent, passed on to the robot
+	Pickup  geo.Point   // the vendor every order on the route comes from
+	Route   []RouteStop // every order the robot picks up on this trip, in drop-off order, OrderID is the first one matched
+}
+
+// whatever the engine was still holding when it stopped, handed back so the caller can save it
+type Unmatched struct {
+	Orders []*OrderItem
+	Robots []RobotItem
+}
+
+type orderEventKind int
+
+const (
+	orderQueued orderEventKind = iota
+	orderMatched
+	orderCancelled
+	orderFailed
+)
+
+// an order out with a robot, the order is kept so it can go back in the queue
+type assignment struct {
+	robot RobotItem
+	order *OrderItem
+}
+
+// a state change of an order waiting to be written to the store
+type orderEvent struct {
+	kind    orderEventKind
+	orderID int
+	robotID string
+}
+
+// events on their way to the store, it has no limit so the engine never waits on a slow database
+type eventQueue struct {
+	mu     sync.Mutex
+	events []orderEvent
+	closed bool
+	ready  chan struct{} // nudged whenever something is queued or it is closed
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(ev orderEvent) {
+	q.mu.Lock()
+	q.events = append(q.events, ev)
+	q.mu.Unlock()
+	q.nudge()
+}
+
+// nothing more gets queued, take still hands out what is left
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.nudge()
+}
+
+func (q *eventQueue) nudge() {
+	select {
+	case q.ready <- struct{}{}:
+	default: // already nudged
+	}
+}
+
+// waits for events and hands back everything queued, false once it is closed and empty
+func (q *eventQueue) take() ([]orderEvent, bool) {
+	for {
+		q.mu.Lock()
+		events, closed := q.events, q.closed
+		q.events = nil
+		q.mu.Unlock()
+
+		if len(events) > 0 {
+			return events, true
+		}
+		if closed {
+			return nil, false
+		}
+		<-q.ready
+	}
 }
 
 type OrderRobotMatcher struct {
-	orderIntake chan (*OrderItem)
-	robotIntake chan (*RobotUpdate)
-	orderQueue  *OrderPQ
-	robotQueue  *RobotQueue
-	orderCount  int64
+	orderIntake    chan (*OrderItem)
+	robotIntake    chan (*RobotUpdate)
+	cancelIntake   chan (*cancelRequest)
+	unassignIntake chan (*unassignRequest)
+	orderQueue     *OrderPQ
+	robotQueue     *RobotQueue
+	active         map[int]assignment // matched orders a robot is still out with
+	orderCount     int64
+	strategy       MatchStrategy
+	done           chan struct{} // closed once the engine has stopped
+	unmatched      Unmatched
+	store          OrderStore
+	events         *eventQueue
+	persisted      chan struct{} // closed once every event has been written
+	batchRadius    float64       // how close drop-offs have to be to share a robot
+	fleet          *robots.Manager
+	positions      PositionSource
+	statuses       StatusSource
+}
+
+// where robots are right now, robot updates only carry a position when the robot's state changes
+type PositionSource interface {
+	Position(robotID string) (geo.Point, bool) // false if the robot hasn't reported lately
+}
+
+// where each order is in its lifecycle, the state manager
+type StatusSource interface {
+	Status(ctx context.Context, orderID int64) (state.Status, error)
+}
+
+type MatcherOption func(*OrderRobotMatcher)
+
+// overrides how far ahead of standard orders each priority tier is ranked
+func WithHeadStarts(headStarts map[Priority]time.Duration) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.orderQueue.headStarts = headStarts
+	}
 }
 
-func CreateOrderRobotMatcher() *OrderRobotMatcher {
-	return &OrderRobotMatcher{
-		orderIntake: make(chan (*OrderItem), 100),
-		robotIntake: make(chan (*RobotUpdate), 100), // this should be a robot update
-		orderQueue:  NewOrderPQ(),
-		robotQueue:  NewRobotQueue(),
-		orderCount:  0,
+// how close two drop-offs from the same vendor have to be for one robot to carry both
+func WithBatchRadius(radius float64) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.batchRadius = radius
 	}
 }
 
-func (orm *OrderRobotMatcher) SubmitOrder(o *OrderItem) {
-	orm.orderIntake <- o
+// has the matcher claim robots through the fleet's state machine instead of trusting the last update it saw
+func WithRobots(fleet *robots.Manager) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.fleet = fleet
+	}
+}
+
+// matches robots by where the source says they are, robots it has nothing fresh for keep the position of their last update
+func WithPositions(positions PositionSource) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.positions = positions
+	}
+}
+
+// checks orders against their status before taking them off a robot, without it every order is assumed not picked up yet
+func WithStatuses(statuses StatusSource) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.statuses = statuses
+	}
 }
 
-func (orm *OrderRobotMatcher) SubmitRobot(r *RobotUpdate) {
-	orm.robotIntake <- r
+// writes every order state change through the store so the queue survives restarts
+func WithStore(store OrderStore) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.store = store
+	}
 }
 
-func (orm *OrderRobotMatcher) attemptMatch(matchesChan chan (*OrderRobotMatch)) {
-	if orm.orderQueue.Len() > 0 && orm.robotQueue.Len() > 0 { // we have at least one order and one robot available
-		orderItem := orm.orderQueue.Pop()
-		robotItem, err := orm.robotQueue.Pop()
-		if err != nil {
-			fmt.Println(err.Error())
+// picks how orders get paired with robots, defaults to greedy nearest robot
+func WithStrategy(strategy MatchStrategy) MatcherOption {
+	return func(orm *OrderRobotMatcher) {
+		orm.strategy = strategy
+	}
+}
+
+func CreateOrderRobotMatcher(opts ...MatcherOption) *OrderRobotMatcher {
+	orm := &OrderRobotMatcher{
+		orderIntake:    make(chan (*OrderItem), 100),
+		robotIntake:    make(chan (*RobotUpdate), 100), // this should be a robot update
+		cancelIntake:   make(chan (*cancelRequest), 100),
+		unassignIntake: make(chan (*unassignRequest), 100),
+		orderQueue:     NewOrderPQ(),
+		robotQueue:     NewRobotQueue(),
+		active:         make(map[int]assignment),
+		orderCount:     0,
+		strategy:       GreedyStrategy{},
+		done:           make(chan struct{}),
+		events:         newEventQueue(),
+		persisted:      make(chan struct{}),
+		batchRadius:    DefaultBatchRadius,
+	}
+	for _, opt := range opts {
+		opt(orm)
+	}
+	return orm
+}
+
+// resubmits every order the store still has waiting on a robot, call after StartORM
+func (orm *OrderRobotMatcher) Restore(ctx context.Context) (int, error) {
+	if orm.store == nil {
+		return 0, nil
+	}
+
+	orders, err := orm.store.LoadUnmatched(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, o := range orders {
+		if err := orm.SubmitOrder(o); err != nil {
+			return i, err
+		}
+	}
+	return len(orders), nil
+}
+
+func (orm *OrderRobotMatcher) SubmitOrder(o *OrderItem) error {
+	select {
+	case <-orm.done:
+		return ErrMatcherStopped
+	default:
+	}
+
+	select {
+	case orm.orderIntake <- o:
+		return nil
+	case <-orm.done:
+		return ErrMatcherStopped
+	}
+}
+
+func (orm *OrderRobotMatcher) SubmitRobot(r *RobotUpdate) error {
+	select {
+	case <-orm.done:
+		return ErrMatcherStopped
+	default:
+	}
+
+	select {
+	case orm.robotIntake <- r:
+		return nil
+	case <-orm.done:
+		return ErrMatcherStopped
+	}
+}
+
+// applies every assignment the strategy comes up with, robots with spare compartments take nearby orders from the same vendor along, unmatched orders go back in the queue
+func (orm *OrderRobotMatcher) attemptMatch(ctx context.Context, matchesChan chan (*OrderRobotMatch)) {
+	if orm.orderQueue.Len() == 0 || orm.robotQueue.Len() == 0 { // need at least one order and one robot available
+		return
+	}
+
+	orders := orm.orderQueue.Drain()
+	assignments := orm.strategy.Match(orders, orm.idleRobots())
+
+	routed := make(map[*OrderItem]bool, len(assignments))
+	skipped := false
+	for _, a := range assignments {
+		if routed[a.Order] { // already riding along with an earlier robot, this robot stays idle for now
+			skipped = true
+			continue
+		}
+		if !orm.robotQueue.Has(a.Robot.robotID) {
+			log.Printf("robot %s was assigned twice", a.Robot.robotID)
+			continue
 		}
 
-		matchesChan <- &OrderRobotMatch{
-			OrderID: orderItem.orderId,
-			RobotID: robotItem.robotID,
+		if err := orm.claimRobot(a.Robot.robotID, a.Order.orderId); err != nil {
+			log.Println(err.Error())
+			orm.robotQueue.Dequeue(a.Robot.robotID) // no longer free, its order gets another go
+			skipped = true
+			continue
 		}
 
-		fmt.Printf("match created between orderId: %d, robotID %s\n", orderItem.orderId, robotItem.robotID)
+		batch := orm.fillCompartments(a.Order, a.Robot, orders, routed)
+		route := buildRoute(batch, a.Robot)
 
+		sent := true
+		select {
+		case matchesChan <- &OrderRobotMatch{
+			OrderID: a.Order.orderId,
+			RobotID: a.Robot.robotID,
+			Pickup:  a.Order.vendorLoc,
+			Route:   route,
+		}:
+		case <-ctx.Done(): // nobody is listening anymore, keep the rest for Unmatched
+			sent = false
+		}
+		if !sent {
+			orm.unclaimRobot(a.Robot.robotID)
+			break
+		}
+
+		orm.robotQueue.Dequeue(a.Robot.robotID)
+		for _, o := range batch {
+			routed[o] = true
+			orm.active[o.orderId] = assignment{robot: a.Robot, order: o}
+			orm.record(orderEvent{kind: orderMatched, orderID: o.orderId, robotID: a.Robot.robotID})
+		}
+
+		log.Printf("match created between orderId: %d, robotID %s (%d orders on route)\n", a.Order.orderId, a.Robot.robotID, len(route))
+	}
+
+	for _, o := range orders {
+		if !routed[o] {
+			orm.orderQueue.Insert(o)
+		}
+	}
+
+	// robots left idle because their order got batched, or orders whose robot went away, get another go
+	if skipped && ctx.Err() == nil {
+		orm.attemptMatch(ctx, matchesChan)
 	}
 }
 
-func (orm *OrderRobotMatcher) StartORM() chan *OrderRobotMatch {
-	matchesQueue := make(chan (*OrderRobotMatch), 10)
-	go orm.startEngine(matchesQueue)
+// runs the engine until ctx is cancelled, the returned channel is closed once it has stopped
+func (orm *OrderRobotMatcher) StartORM(ctx context.Context) <-chan *OrderRobotMatch {
+	matchesQueue := make(chan (*OrderRobotMatch), 100)
+	go orm.persistEvents()
+	go orm.startEngine(ctx, matchesQueue)
 	return matchesQueue
 }
 
-func (orm *OrderRobotMatcher) startEngine(matchesChan chan (*OrderRobotMatch)) {
+// blocks until the engine has stopped and returns the orders and robots it never matched
+func (orm *OrderRobotMatcher) Wait() Unmatched {
+	<-orm.done
+	<-orm.persisted
+
+	// anything that slipped into the intakes while the engine was stopping
+	for {
+		select {
+		case o := <-orm.orderIntake:
+			orm.unmatched.Orders = append(orm.unmatched.Orders, o)
+		case <-orm.robotIntake:
+		default:
+			return orm.unmatched
+		}
+	}
+}
+
+func (orm *OrderRobotMatcher) record(ev orderEvent) {
+	if orm.store == nil {
+		return
+	}
+	orm.events.push(ev)
+}
+
+// writes order events in the order they happened, off the engine loop so a slow database never stalls matching
+func (orm *OrderRobotMatcher) persistEvents() {
+	defer close(orm.persisted)
+
+	for {
+		events, ok := orm.events.take()
+		if !ok {
+			return
+		}
+		for _, ev := range events {
+			orm.persist(ev)
+		}
+	}
+}
+
+func (orm *OrderRobotMatcher) persist(ev orderEvent) {
+	// not tied to the engine ctx, the last events are written while shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	switch ev.kind {
+	case orderQueued:
+		err = orm.store.OrderQueued(ctx, ev.orderID)
+	case orderMatched:
+		err = orm.store.OrderMatched(ctx, ev.orderID, ev.robotID)
+	case orderCancelled:
+		err = orm.store.OrderCancelled(ctx, ev.orderID)
+	case orderFailed:
+		err = orm.store.OrderFailed(ctx, ev.orderID)
+	}
+	if err != nil {
+		log.Printf("failed persisting order %d: %v\n", ev.orderID, err)
+	}
+}
+
+func (orm *OrderRobotMatcher) queueOrder(orderReq *OrderItem) {
+	orm.orderCount++
+	orderReq.UpdateOrderNum(int(orm.orderCount))
+	orm.orderQueue.Insert(orderReq) // put in heap
+	orm.record(orderEvent{kind: orderQueued, orderID: orderReq.orderId})
+}
+
+func (orm *OrderRobotMatcher) applyRobotUpdate(robotUpdate *RobotUpdate) {
+	var err error
+
+	state := robotUpdate.status
+	if orm.fleet != nil { // the update may be stale by the time we see it
+		state = orm.fleet.State(robotUpdate.robotID)
+	}
+
+	if state.Available() {
+		orm.releaseRobot(robotUpdate.robotID)
+	}
+
+	robot := RobotItem{
+		robotID:      robotUpdate.robotID,
+		loc:          robotUpdate.loc,
+		compartments: robotUpdate.compartments,
+		free:         robotUpdate.free,
+	}
+	idle := state.Available() && robot.Capacity() > 0 && !orm.benched(robot.robotID) // a robot with every compartment full can't take anything
+
+	if idle && orm.robotQueue.Has(robot.robotID) { // already waiting, robot just moved
+		err = orm.robotQueue.Update(robot)
+	} else if idle { // add to queue
+		err = orm.robotQueue.Enqueue(robot)
+	} else if orm.robotQueue.Has(robot.robotID) { // busy, offline or faulted
+		err = orm.robotQueue.Dequeue(robotUpdate.robotID)
+	}
+
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// idle robots in queue order, at their live position if there is one
+func (orm *OrderRobotMatcher) idleRobots() []RobotItem {
+	items := orm.robotQueue.Items()
+	if orm.positions == nil {
+		return items
+	}
+	for i := range items {
+		if loc, ok := orm.positions.Position(items[i].robotID); ok {
+			items[i].loc = loc
+		}
+	}
+	return items
+}
+
+// moves the robot to assigned, without a fleet every queued robot is assumed free
+func (orm *OrderRobotMatcher) claimRobot(robotID string, orderID int) error {
+	if orm.fleet == nil {
+		return nil
+	}
+	return orm.fleet.Assign(robotID, fmt.Sprintf("matched order %d", orderID))
+}
+
+// draining robots, ones sitting out a failed assignment and ones that went quiet don't get routes
+func (orm *OrderRobotMatcher) benched(robotID string) bool {
+	return orm.fleet != nil && (orm.fleet.Draining(robotID) || !orm.fleet.Healthy(robotID) || orm.fleet.Stale(robotID))
+}
+
+// the route never went out, the robot is still free
+func (orm *OrderRobotMatcher) unclaimRobot(robotID string) {
+	if orm.fleet == nil {
+		return
+	}
+	if err := orm.fleet.Transition(robotID, robots.StateIdle, "route never sent"); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// takes in everything already waiting on the intakes so a burst of arrivals gets matched as one batch
+func (orm *OrderRobotMatcher) drainIntake() {
+	for {
+		select {
+		case orderReq := <-orm.orderIntake:
+			orm.queueOrder(orderReq)
+		case robotUpdate := <-orm.robotIntake:
+			orm.applyRobotUpdate(robotUpdate)
+		default:
+			return
+		}
+	}
+}
+
+func (orm *OrderRobotMatcher) shutdown(matchesChan chan (*OrderRobotMatch)) {
+	orm.drainIntake()
+	orm.unmatched = Unmatched{
+		Orders: orm.orderQueue.Drain(),
+		Robots: orm.robotQueue.Items(),
+	}
+	log.Printf("matcher stopped with %d unmatched orders and %d idle robots\n", len(orm.unmatched.Orders), len(orm.unmatched.Robots))
+
+	orm.events.close()
+	close(matchesChan)
+	close(orm.done)
+}
+
+// arrivals are matched right away, the ticker only catches anything left over
+func (orm *OrderRobotMatcher) startEngine(ctx context.Context, matchesChan chan (*OrderRobotMatch)) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
+	defer orm.shutdown(matchesChan)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
+
 		case orderReq := <-orm.orderIntake: // get order request
-			orm.orderCount++
-			orderReq.UpdateOrderNum(int(orm.orderCount))
-			orm.orderQueue.Insert(orderReq) // put in heap
+			orm.queueOrder(orderReq)
+			orm.drainIntake()
+			orm.attemptMatch(ctx, matchesChan)
 
 		case robotUpdate := <-orm.robotIntake:
-			var err error
+			orm.applyRobotUpdate(robotUpdate)
+			orm.drainIntake()
+			orm.attemptMatch(ctx, matchesChan)
 
-			if robotUpdate.status == "online" { // add to queue
-				err = orm.robotQueue.Enqueue(RobotItem{
-					robotID: robotUpdate.robotID,
-				})
-			} else {
-				err = orm.robotQueue.Dequeue(robotUpdate.robotID) //
-			}
+		case cancelReq := <-orm.cancelIntake:
+			orm.drainIntake() // the order may still be sitting in the intake
+			cancelReq.result <- orm.applyCancel(ctx, cancelReq, matchesChan)
+			orm.attemptMatch(ctx, matchesChan) // a recalled robot is free again
 
-			if err != nil {
-				fmt.Println(err.Error())
-			}
+		case unassignReq := <-orm.unassignIntake:
+			orm.drainIntake()
+			unassignReq.result <- orm.applyUnassign(ctx, unassignReq, matchesChan)
+			orm.attemptMatch(ctx, matchesChan) // the orders are back in the queue
 
 		case <-ticker.C:
-			orm.attemptMatch(matchesChan)
+			orm.attemptMatch(ctx, matchesChan)
 		}
 	}
 }
@@ -1,9 +1,16 @@
 package matcher
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"testing"
 	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
 )
 
 func TestCreateOrderRobotMatcher(t *testing.T) {
@@ -71,7 +78,7 @@ func TestSubmitRobot(t *testing.T) {
 
 	robot := &RobotUpdate{
 		robotID: "robot-1",
-		status:  "online",
+		status:  robots.StateIdle,
 	}
 
 	// Submit robot in a goroutine to avoid blocking
@@ -103,7 +110,7 @@ func TestAttemptMatchNoOrdersOrRobots(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
 	matchesChan := make(chan *OrderRobotMatch, 10)
 
-	orm.attemptMatch(matchesChan)
+	orm.attemptMatch(context.Background(), matchesChan)
 
 	// Should not produce a match
 	select {
@@ -130,7 +137,7 @@ func TestAttemptMatchWithOrderAndRobot(t *testing.T) {
 	}
 	orm.robotQueue.Enqueue(robot)
 
-	orm.attemptMatch(matchesChan)
+	orm.attemptMatch(context.Background(), matchesChan)
 
 	// Should produce a match
 	select {
@@ -146,10 +153,92 @@ func TestAttemptMatchWithOrderAndRobot(t *testing.T) {
 	}
 }
 
+func TestAttemptMatchPicksNearestRobot(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	// vendor sits at (10, 10)
+	orm.orderQueue.Insert(CreateOrder("user-1", 1, 1, geo.Point{X: 10, Y: 10}, geo.Point{X: 50, Y: 50}, PriorityStandard))
+
+	// the far robot has been waiting the longest, FIFO would pick it
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-far", loc: geo.Point{X: 100, Y: 100}})
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-near", loc: geo.Point{X: 12, Y: 9}})
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-mid", loc: geo.Point{X: 30, Y: 30}})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	select {
+	case match := <-matchesChan:
+		if match.RobotID != "robot-near" {
+			t.Errorf("expected RobotID robot-near, got %s", match.RobotID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match but none was produced")
+	}
+
+	if orm.robotQueue.Len() != 2 {
+		t.Errorf("expected 2 idle robots left, got %d", orm.robotQueue.Len())
+	}
+}
+
+type fixedPositions map[string]geo.Point
+
+func (p fixedPositions) Position(robotID string) (geo.Point, bool) {
+	loc, ok := p[robotID]
+	return loc, ok
+}
+
+func TestAttemptMatchUsesLivePositions(t *testing.T) {
+	// robot-a last updated next to the vendor but has since driven off, robot-b has no live position
+	orm := CreateOrderRobotMatcher(WithPositions(fixedPositions{"robot-a": {X: 100, Y: 100}}))
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	orm.orderQueue.Insert(CreateOrder("user-1", 1, 1, geo.Point{X: 10, Y: 10}, geo.Point{X: 50, Y: 50}, PriorityStandard))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-a", loc: geo.Point{X: 10, Y: 10}})
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-b", loc: geo.Point{X: 20, Y: 20}})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	select {
+	case match := <-matchesChan:
+		if match.RobotID != "robot-b" {
+			t.Errorf("expected robot-b, robot-a is far away now, got %s", match.RobotID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match but none was produced")
+	}
+}
+
+func TestEngineMatchesNearestIdleRobot(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
+
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-far", geo.Point{X: -40, Y: 0}))
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-near", geo.Point{X: 40, Y: 0}))
+
+	// robot-far drives over to the vendor while still idle
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-far", geo.Point{X: 1, Y: 0}))
+
+	orm.SubmitOrder(CreateOrder("user-1", 7, 0, geo.Point{X: 0, Y: 0}, geo.Point{X: 5, Y: 5}, PriorityStandard))
+
+	select {
+	case match := <-matchesChan:
+		if match.RobotID != "robot-far" {
+			t.Errorf("expected RobotID robot-far after it moved, got %s", match.RobotID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected match to be created within 2 seconds")
+	}
+}
+
 func TestStartORMReturnsChannel(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
 
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
 
 	if matchesChan == nil {
 		t.Fatal("StartORM returned nil channel")
@@ -158,7 +247,9 @@ func TestStartORMReturnsChannel(t *testing.T) {
 
 func TestEngineProcessesOrders(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orm.StartORM(ctx)
 
 	// Submit an order
 	order := &OrderItem{
@@ -166,53 +257,52 @@ func TestEngineProcessesOrders(t *testing.T) {
 	}
 	orm.SubmitOrder(order)
 
-	// Give engine time to process
-	time.Sleep(100 * time.Millisecond)
+	// the engine owns its queues, only look at them once it has stopped
+	cancel()
+	unmatched := orm.Wait()
 
 	// Verify order was added to queue and orderCount incremented
 	if orm.orderCount != 1 {
 		t.Errorf("expected orderCount 1, got %d", orm.orderCount)
 	}
 
-	if orm.orderQueue.Len() != 1 {
-		t.Errorf("expected orderQueue length 1, got %d", orm.orderQueue.Len())
+	if len(unmatched.Orders) != 1 {
+		t.Errorf("expected 1 queued order, got %d", len(unmatched.Orders))
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineProcessesRobotOnline(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orm.StartORM(ctx)
 
 	// Submit a robot with online status
 	robot := &RobotUpdate{
 		robotID: "robot-online",
-		status:  "online",
+		status:  robots.StateIdle,
 	}
 	orm.SubmitRobot(robot)
 
-	// Give engine time to process
-	time.Sleep(100 * time.Millisecond)
+	cancel()
+	unmatched := orm.Wait()
 
 	// Verify robot was added to queue
-	if orm.robotQueue.Len() != 1 {
-		t.Errorf("expected robotQueue length 1, got %d", orm.robotQueue.Len())
+	if len(unmatched.Robots) != 1 {
+		t.Errorf("expected 1 queued robot, got %d", len(unmatched.Robots))
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineProcessesRobotOffline(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orm.StartORM(ctx)
 
 	// First add a robot
 	robot := &RobotUpdate{
 		robotID: "robot-test",
-		status:  "online",
+		status:  robots.StateIdle,
 	}
 	orm.SubmitRobot(robot)
 	time.Sleep(100 * time.Millisecond)
@@ -220,23 +310,24 @@ func TestEngineProcessesRobotOffline(t *testing.T) {
 	// Now send offline status
 	robotOffline := &RobotUpdate{
 		robotID: "robot-test",
-		status:  "offline",
+		status:  robots.StateOffline,
 	}
 	orm.SubmitRobot(robotOffline)
-	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	unmatched := orm.Wait()
 
 	// Verify robot was removed from queue
-	if orm.robotQueue.Len() != 0 {
-		t.Errorf("expected robotQueue length 0, got %d", orm.robotQueue.Len())
+	if len(unmatched.Robots) != 0 {
+		t.Errorf("expected no queued robots, got %d", len(unmatched.Robots))
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineCreatesMatchesOnTicker(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
 
 	// Submit order and robot
 	order := &OrderItem{
@@ -246,7 +337,7 @@ func TestEngineCreatesMatchesOnTicker(t *testing.T) {
 
 	robot := &RobotUpdate{
 		robotID: "robot-222",
-		status:  "online",
+		status:  robots.StateIdle,
 	}
 	orm.SubmitRobot(robot)
 
@@ -262,14 +353,13 @@ func TestEngineCreatesMatchesOnTicker(t *testing.T) {
 	case <-time.After(2 * time.Second):
 		t.Fatal("Expected match to be created within 2 seconds")
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineMultipleMatches(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
 
 	// Submit multiple orders and robots
 	for i := 1; i <= 3; i++ {
@@ -280,14 +370,14 @@ func TestEngineMultipleMatches(t *testing.T) {
 
 		robot := &RobotUpdate{
 			robotID: fmt.Sprintf("robot-%d", i),
-			status:  "online",
+			status:  robots.StateIdle,
 		}
 		orm.SubmitRobot(robot)
 	}
 
-	// Collect matches (should get 3 matches over ~3 seconds)
+	// Collect matches (arrivals are matched right away, no waiting on the ticker)
 	matchCount := 0
-	timeout := time.After(5 * time.Second)
+	timeout := time.After(500 * time.Millisecond)
 
 	for matchCount < 3 {
 		select {
@@ -304,14 +394,13 @@ func TestEngineMultipleMatches(t *testing.T) {
 	if matchCount != 3 {
 		t.Errorf("expected 3 matches, got %d", matchCount)
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineOrderCountIncrement(t *testing.T) {
 	orm := CreateOrderRobotMatcher()
-	matchesChan := orm.StartORM()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orm.StartORM(ctx)
 
 	// Submit multiple orders
 	for i := 0; i < 5; i++ {
@@ -321,15 +410,12 @@ func TestEngineOrderCountIncrement(t *testing.T) {
 		orm.SubmitOrder(order)
 	}
 
-	// Give engine time to process all orders
-	time.Sleep(200 * time.Millisecond)
+	cancel()
+	orm.Wait()
 
 	if orm.orderCount != 5 {
 		t.Errorf("expected orderCount 5, got %d", orm.orderCount)
 	}
-
-	// Clean up
-	close(matchesChan)
 }
 
 func TestEngineChannelBuffering(t *testing.T) {
@@ -348,7 +434,7 @@ func TestEngineChannelBuffering(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		robot := &RobotUpdate{
 			robotID: fmt.Sprintf("robot-%d", i),
-			status:  "online",
+			status:  robots.StateIdle,
 		}
 		// Should not block
 		orm.SubmitRobot(robot)
@@ -356,3 +442,205 @@ func TestEngineChannelBuffering(t *testing.T) {
 
 	// If we got here without blocking, test passes
 }
+
+func TestAttemptMatchDrainsBacklogInOneTick(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 30)
+
+	for i := 1; i <= 30; i++ {
+		orm.orderQueue.Insert(CreateOrder("user", i, i, geo.Point{X: i, Y: 0}, geo.Point{}, PriorityStandard))
+		orm.robotQueue.Enqueue(RobotItem{robotID: fmt.Sprintf("robot-%d", i), loc: geo.Point{X: 0, Y: i}})
+	}
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	if len(matchesChan) != 30 {
+		t.Errorf("expected 30 matches from one tick, got %d", len(matchesChan))
+	}
+	if orm.orderQueue.Len() != 0 || orm.robotQueue.Len() != 0 {
+		t.Errorf("expected both queues empty, got %d orders and %d robots", orm.orderQueue.Len(), orm.robotQueue.Len())
+	}
+}
+
+func TestAttemptMatchKeepsUnmatchedOrders(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	for i := 1; i <= 5; i++ {
+		orm.orderQueue.Insert(CreateOrder("user", i, i, geo.Point{}, geo.Point{}, PriorityStandard))
+	}
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1"})
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-2"})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	if len(matchesChan) != 2 {
+		t.Errorf("expected 2 matches, got %d", len(matchesChan))
+	}
+	if orm.orderQueue.Len() != 3 {
+		t.Errorf("expected 3 orders left waiting, got %d", orm.orderQueue.Len())
+	}
+
+	// the oldest orders are served first
+	for match := range len(matchesChan) {
+		if m := <-matchesChan; m.OrderID > 2 {
+			t.Errorf("match %d went to order %d ahead of older orders", match, m.OrderID)
+		}
+	}
+}
+
+// matches per second the running engine sustains when orders and robots arrive together
+func BenchmarkEngineThroughput(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
+
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, fmt.Sprintf("robot-%d", i), geo.Point{X: i % 100, Y: i % 37}))
+			orm.SubmitOrder(CreateOrder("user", i, 0, geo.Point{X: i % 53, Y: i % 71}, geo.Point{}, PriorityStandard))
+		}
+	}()
+
+	for i := 0; i < b.N; i++ {
+		<-matchesChan
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "matches/s")
+}
+
+// cost of matching a full backlog in one tick for each strategy
+func BenchmarkAttemptMatchBacklog(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, strategy := range []MatchStrategy{FIFOStrategy{}, GreedyStrategy{}, HungarianStrategy{}} {
+		for _, size := range []int{30, 200} {
+			b.Run(fmt.Sprintf("%s/%d", strategy.Name(), size), func(b *testing.B) {
+				orm := CreateOrderRobotMatcher(WithStrategy(strategy))
+				matchesChan := make(chan *OrderRobotMatch, size)
+				matches := 0
+
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					for i := 0; i < size; i++ {
+						orm.orderQueue.Insert(CreateOrder("user", i, i, geo.Point{X: (i * 7) % 101, Y: (i * 13) % 97}, geo.Point{}, PriorityStandard))
+						orm.robotQueue.Enqueue(RobotItem{robotID: fmt.Sprintf("robot-%d", i), loc: geo.Point{X: (i * 11) % 89, Y: (i * 3) % 83}})
+					}
+					b.StartTimer()
+
+					orm.attemptMatch(context.Background(), matchesChan)
+
+					b.StopTimer()
+					for len(matchesChan) > 0 {
+						<-matchesChan
+						matches++
+					}
+					b.StartTimer()
+				}
+				b.ReportMetric(float64(matches)/b.Elapsed().Seconds(), "matches/s")
+			})
+		}
+	}
+}
+
+func TestEngineShutdownClosesMatches(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	matchesChan := orm.StartORM(ctx)
+
+	cancel()
+
+	select {
+	case _, ok := <-matchesChan:
+		if ok {
+			t.Error("expected no match after shutdown")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("matches channel was not closed on shutdown")
+	}
+
+	if err := orm.SubmitOrder(CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, PriorityStandard)); err != ErrMatcherStopped {
+		t.Errorf("expected ErrMatcherStopped, got %v", err)
+	}
+	if err := orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{})); err != ErrMatcherStopped {
+		t.Errorf("expected ErrMatcherStopped, got %v", err)
+	}
+}
+
+func TestEngineShutdownReturnsUnmatched(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	matchesChan := orm.StartORM(ctx)
+
+	// one robot and three orders, two orders must come back
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	for i := 1; i <= 3; i++ {
+		orm.SubmitOrder(CreateOrder("user", i, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+	}
+
+	select {
+	case <-matchesChan:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match before shutdown")
+	}
+
+	cancel()
+	unmatched := orm.Wait()
+
+	if len(unmatched.Orders) != 2 {
+		t.Errorf("expected 2 unmatched orders, got %d", len(unmatched.Orders))
+	}
+	if len(unmatched.Robots) != 0 {
+		t.Errorf("expected no idle robots, got %d", len(unmatched.Robots))
+	}
+}
+
+func TestEngineShutdownReturnsIdleRobots(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	ctx, cancel := context.WithCancel(context.Background())
+	orm.StartORM(ctx)
+
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-2", geo.Point{}))
+
+	cancel()
+	unmatched := orm.Wait()
+
+	if len(unmatched.Robots) != 2 {
+		t.Errorf("expected 2 idle robots, got %d", len(unmatched.Robots))
+	}
+}
+
+func TestAttemptMatchSkipsRobotsTheFleetHasClaimed(t *testing.T) {
+	fleet := robots.NewManager()
+	orm := CreateOrderRobotMatcher(WithRobots(fleet))
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	fleet.Transition("robot-busy", robots.StateIdle, "test")
+	fleet.Transition("robot-busy", robots.StateAssigned, "taken elsewhere")
+	fleet.Transition("robot-free", robots.StateIdle, "test")
+
+	orm.orderQueue.Insert(CreateOrder("user", 1, 1, geo.Point{}, geo.Point{}, PriorityStandard))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-busy"}) // nearest, but no longer free
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-free", loc: geo.Point{X: 50, Y: 50}})
+
+	orm.attemptMatch(context.Background(), matchesChan)
+
+	if len(matchesChan) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matchesChan))
+	}
+	if m := <-matchesChan; m.RobotID != "robot-free" {
+		t.Errorf("expected robot-free to get the order, got %s", m.RobotID)
+	}
+	if got := fleet.State("robot-free"); got != robots.StateAssigned {
+		t.Errorf("expected robot-free to be assigned, got %s", got)
+	}
+	if orm.robotQueue.Has("robot-busy") {
+		t.Error("expected the claimed robot to be dropped from the queue")
+	}
+}
@@ -1,11 +1,39 @@
 package matcher
 
-import "container/heap"
+import (
+	"container/heap"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// same numbering as the OrderPriority enum in order_service.proto
+type Priority int
+
+const (
+	PriorityStandard Priority = iota
+	PriorityExpress
+	PriorityStaff
+	PriorityScheduled
+)
+
+// how much earlier than its real queue time each tier is treated as having arrived.
+// a scheduled order placed now ranks like a standard order placed 10 minutes from now
+var DefaultHeadStarts = map[Priority]time.Duration{
+	PriorityExpress:   10 * time.Minute,
+	PriorityStaff:     5 * time.Minute,
+	PriorityStandard:  0,
+	PriorityScheduled: -10 * time.Minute,
+}
 
 type OrderItem struct {
-	ownerId  string //user id who placed order
-	orderId  int    //unique order id in DB
-	orderNum int    // this is the actual order number given for the day
+	ownerId      string    //user id who placed order
+	orderId      int       //unique order id in DB
+	orderNum     int       // this is the actual order number given for the day
+	vendorLoc    geo.Point // where the robot picks the order up
+	dropoffLoc   geo.Point // where the robot drops the order off
+	priority     Priority
+	waitingSince time.Time // when the order was placed, stamped on queueing if unknown
 }
 
 type Item struct {
@@ -14,14 +42,28 @@ type Item struct {
 	Index    int
 }
 
-func CreateOrder(ownerId string, orderId int, orderNum int) *OrderItem {
+func CreateOrder(ownerId string, orderId int, orderNum int, vendorLoc geo.Point, dropoffLoc geo.Point, priority Priority) *OrderItem {
 	return &OrderItem{
-		ownerId:  ownerId,
-		orderId:  orderId,
-		orderNum: orderNum,
+		ownerId:    ownerId,
+		orderId:    orderId,
+		orderNum:   orderNum,
+		vendorLoc:  vendorLoc,
+		dropoffLoc: dropoffLoc,
+		priority:   priority,
 	}
 }
 
+func (o *OrderItem) OrderID() int { return o.orderId }
+
+func (o *OrderItem) OwnerID() string { return o.ownerId }
+
+func (o *OrderItem) Priority() Priority { return o.priority }
+
+// lets restored orders keep aging from when they were actually placed
+func (o *OrderItem) SetWaitingSince(t time.Time) {
+	o.waitingSince = t
+}
+
 func (o *OrderItem) UpdateOrderNum(orderNum int) {
 	o.orderNum = orderNum
 }
@@ -31,7 +73,10 @@ type OrderQueue []*Item
 func (pq OrderQueue) Len() int { return len(pq) }
 
 func (pq OrderQueue) Less(i, j int) bool {
-	return pq[i].Priority < pq[j].Priority
+	if pq[i].Priority != pq[j].Priority {
+		return pq[i].Priority < pq[j].Priority
+	}
+	return pq[i].Value.(*OrderItem).orderNum < pq[j].Value.(*OrderItem).orderNum
 }
 
 func (pq OrderQueue) Swap(i, j int) {
@@ -59,19 +104,32 @@ func (pq *OrderQueue) Pop() any {
 }
 
 type OrderPQ struct {
-	h OrderQueue
+	h          OrderQueue
+	pos        map[int]*Item // order id to its heap item, Index tracks where it sits
+	headStarts map[Priority]time.Duration
 }
 
 func NewOrderPQ() *OrderPQ {
-	pq := &OrderPQ{h: make(OrderQueue, 0)}
+	pq := &OrderPQ{h: make(OrderQueue, 0), pos: make(map[int]*Item), headStarts: DefaultHeadStarts}
 	heap.Init(&pq.h)
 
 	return pq
 }
 
+// effective priority of an order, lower goes first. an order's urgency is its head start plus how long it
+// has waited, and since every waiting order ages at the same rate their relative order never changes,
+// so ranking by queue time minus head start gives the same result without ever re-heapifying
+func (pq *OrderPQ) rank(o *OrderItem) int {
+	return int(o.waitingSince.Add(-pq.headStarts[o.priority]).UnixNano())
+}
+
 func (pq *OrderPQ) Insert(orderItem *OrderItem) {
-	item := &Item{Priority: orderItem.orderNum, Value: orderItem}
+	if orderItem.waitingSince.IsZero() {
+		orderItem.waitingSince = time.Now()
+	}
+	item := &Item{Priority: pq.rank(orderItem), Value: orderItem}
 	heap.Push(&pq.h, item)
+	pq.pos[orderItem.orderId] = item
 }
 
 func (pq *OrderPQ) Pop() *OrderItem {
@@ -79,9 +137,32 @@ func (pq *OrderPQ) Pop() *OrderItem {
 		return nil
 	}
 
-	return heap.Pop(&pq.h).(*Item).Value.(*OrderItem)
+	orderItem := heap.Pop(&pq.h).(*Item).Value.(*OrderItem)
+	delete(pq.pos, orderItem.orderId)
+	return orderItem
+}
+
+// takes an order out from anywhere in the heap, nil if it isn't queued
+func (pq *OrderPQ) Remove(orderId int) *OrderItem {
+	item, ok := pq.pos[orderId]
+	if !ok {
+		return nil
+	}
+
+	heap.Remove(&pq.h, item.Index)
+	delete(pq.pos, orderId)
+	return item.Value.(*OrderItem)
 }
 
 func (pq *OrderPQ) Len() int {
 	return pq.h.Len()
 }
+
+// pops every order in priority order, leaving the queue empty
+func (pq *OrderPQ) Drain() []*OrderItem {
+	orders := make([]*OrderItem, 0, pq.Len())
+	for pq.Len() > 0 {
+		orders = append(orders, pq.Pop())
+	}
+	return orders
+}
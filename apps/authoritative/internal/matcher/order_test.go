@@ -0,0 +1,95 @@
+package matcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func orderWaiting(orderId int, priority Priority, waited time.Duration) *OrderItem {
+	o := CreateOrder("user", orderId, orderId, geo.Point{}, geo.Point{}, priority)
+	o.SetWaitingSince(time.Now().Add(-waited))
+	return o
+}
+
+func TestOrderPQHigherTierGoesFirst(t *testing.T) {
+	pq := NewOrderPQ()
+
+	pq.Insert(orderWaiting(1, PriorityScheduled, time.Minute))
+	pq.Insert(orderWaiting(2, PriorityStandard, time.Minute))
+	pq.Insert(orderWaiting(3, PriorityStaff, time.Minute))
+	pq.Insert(orderWaiting(4, PriorityExpress, time.Minute))
+
+	for _, expected := range []int{4, 3, 2, 1} {
+		if got := pq.Pop().orderId; got != expected {
+			t.Errorf("expected order %d, got %d", expected, got)
+		}
+	}
+}
+
+func TestOrderPQAgingPreventsStarvation(t *testing.T) {
+	pq := NewOrderPQ()
+
+	// the scheduled order has waited longer than the express head start plus its own penalty
+	pq.Insert(orderWaiting(1, PriorityScheduled, 25*time.Minute))
+	pq.Insert(orderWaiting(2, PriorityExpress, 0))
+	pq.Insert(orderWaiting(3, PriorityExpress, time.Second))
+
+	if got := pq.Pop().orderId; got != 1 {
+		t.Errorf("expected the long waiting scheduled order first, got %d", got)
+	}
+	if got := pq.Pop().orderId; got != 3 {
+		t.Errorf("expected the older express order next, got %d", got)
+	}
+}
+
+func TestOrderPQSameRankFallsBackToOrderNum(t *testing.T) {
+	pq := NewOrderPQ()
+	since := time.Now()
+
+	for _, orderId := range []int{3, 1, 2} {
+		o := CreateOrder("user", orderId, orderId, geo.Point{}, geo.Point{}, PriorityStandard)
+		o.SetWaitingSince(since)
+		pq.Insert(o)
+	}
+
+	for _, expected := range []int{1, 2, 3} {
+		if got := pq.Pop().orderId; got != expected {
+			t.Errorf("expected order %d, got %d", expected, got)
+		}
+	}
+}
+
+func TestWithHeadStartsOverridesTiers(t *testing.T) {
+	orm := CreateOrderRobotMatcher(WithHeadStarts(map[Priority]time.Duration{
+		PriorityScheduled: time.Hour, // flip scheduled ahead of everything
+	}))
+
+	orm.orderQueue.Insert(orderWaiting(1, PriorityExpress, time.Minute))
+	orm.orderQueue.Insert(orderWaiting(2, PriorityScheduled, 0))
+
+	if got := orm.orderQueue.Pop().orderId; got != 2 {
+		t.Errorf("expected the scheduled order first, got %d", got)
+	}
+}
+
+func TestOrderPQRemoveByID(t *testing.T) {
+	pq := NewOrderPQ()
+	for i := 1; i <= 5; i++ {
+		pq.Insert(orderWaiting(i, PriorityStandard, time.Duration(10-i)*time.Minute))
+	}
+
+	if removed := pq.Remove(3); removed == nil || removed.orderId != 3 {
+		t.Fatalf("expected order 3 to be removed, got %v", removed)
+	}
+	if removed := pq.Remove(3); removed != nil {
+		t.Errorf("expected nothing when removing order 3 twice, got %d", removed.orderId)
+	}
+
+	for _, expected := range []int{1, 2, 4, 5} {
+		if got := pq.Pop().orderId; got != expected {
+			t.Errorf("expected order %d, got %d", expected, got)
+		}
+	}
+}
@@ -4,22 +4,54 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
 )
 
 type RobotUpdate struct {
-	status  string
-	robotID string
+	status       robots.State
+	robotID      string
+	loc          geo.Point // last reported position of the robot
+	compartments int       // 0 means the robot didn't say, treated as a single compartment
+	free         int
 }
 
-func NewRobotUpdate(status string, robotID string) *RobotUpdate {
+func NewRobotUpdate(status robots.State, robotID string, loc geo.Point) *RobotUpdate {
 	return &RobotUpdate{
 		status:  status,
 		robotID: robotID,
+		loc:     loc,
 	}
 }
 
+// for robots with more than one compartment, free is how many of them are empty
+func (r *RobotUpdate) WithCapacity(compartments int, free int) *RobotUpdate {
+	r.compartments = compartments
+	r.free = free
+	return r
+}
+
 type RobotItem struct {
-	robotID string
+	robotID      string
+	loc          geo.Point
+	compartments int
+	free         int // empty compartments, compartments fill from 0 so the first free one is compartments - free
+}
+
+func (r RobotItem) RobotID() string { return r.robotID }
+
+// how many orders the robot can take on one trip
+func (r RobotItem) Capacity() int {
+	if r.compartments <= 0 { // older robots don't report capacity
+		return 1
+	}
+	return r.free
+}
+
+// estimated cost for a robot to get to the vendor of an order, for now just the distance
+func pickupCost(r RobotItem, o *OrderItem) float64 {
+	return geo.Distance(r.loc, o.vendorLoc)
 }
 
 type RobotQueue struct {
@@ -49,6 +81,23 @@ func (q *RobotQueue) Enqueue(r RobotItem) error {
 	return nil
 }
 
+// refreshes the position and capacity of an already queued robot, keeps its place in the queue
+func (q *RobotQueue) Update(r RobotItem) error {
+	el := q.pos[r.robotID]
+
+	if el == nil {
+		return fmt.Errorf("robot of Id %s does not exist", r.robotID)
+	}
+
+	el.Value = r
+	return nil
+}
+
+func (q *RobotQueue) Has(rID string) bool {
+	_, exists := q.pos[rID]
+	return exists
+}
+
 func (q *RobotQueue) Dequeue(rID string) error {
 	el := q.pos[rID]
 
@@ -74,3 +123,12 @@ func (q *RobotQueue) Pop() (*RobotItem, error) {
 	}
 	return &robotEl, nil
 }
+
+// idle robots from the one waiting the longest to the newest
+func (q *RobotQueue) Items() []RobotItem {
+	items := make([]RobotItem, 0, q.queue.Len())
+	for el := q.queue.Front(); el != nil; el = el.Next() {
+		items = append(items, el.Value.(RobotItem))
+	}
+	return items
+}
@@ -0,0 +1,98 @@
+package matcher
+
+// persistence for the order queue so a restart of the authoritative server doesn't drop orders
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// records every state change of an order the matcher makes, and hands back the orders still waiting on a robot
+type OrderStore interface {
+	LoadUnmatched(ctx context.Context) ([]*OrderItem, error)
+	OrderQueued(ctx context.Context, orderID int) error
+	OrderMatched(ctx context.Context, orderID int, robotID string) error
+	OrderCancelled(ctx context.Context, orderID int) error
+	OrderFailed(ctx context.Context, orderID int) error
+}
+
+// status changes go through the order lifecycle so they are checked against whatever robots and clients did to the order
+type DBOrderStore struct {
+	db     db.Store
+	states *state.Manager
+}
+
+func NewDBOrderStore(database db.Store, states *state.Manager) *DBOrderStore {
+	return &DBOrderStore{db: database, states: states}
+}
+
+// pending orders never made it into the queue, queued ones were waiting when we went down.
+// matched ones lost their route with the fleet, queueing them again moves them back to
+// queued and clears the robot through OrderQueued
+func (s *DBOrderStore) LoadUnmatched(ctx context.Context) ([]*OrderItem, error) {
+	orders, err := s.db.ListOrdersByStatus(ctx, db.OrderStatusPending, db.OrderStatusQueued, db.OrderStatusMatched)
+	if err != nil {
+		return nil, err
+	}
+
+	vendorLocs := make(map[string]geo.Point) // most orders share a handful of vendors
+	items := make([]*OrderItem, 0, len(orders))
+	for _, o := range orders {
+		vendorLoc, ok := vendorLocs[o.VendorID]
+		if !ok {
+			vendor, err := s.db.GetVendor(ctx, o.VendorID)
+			if err != nil {
+				return nil, fmt.Errorf("order %d: %w", o.ID, err)
+			}
+			coord, err := s.db.GetCoordinate(ctx, vendor.Coordinates)
+			if err != nil {
+				return nil, fmt.Errorf("order %d: %w", o.ID, err)
+			}
+			vendorLoc = coord.Point()
+			vendorLocs[o.VendorID] = vendorLoc
+		}
+
+		dropoff, err := s.db.GetCoordinate(ctx, o.DropOffLocation)
+		if err != nil {
+			return nil, fmt.Errorf("order %d: %w", o.ID, err)
+		}
+
+		item := CreateOrder(o.UserID, int(o.ID), 0, vendorLoc, dropoff.Point(), Priority(o.Priority))
+		if createdAt, err := time.Parse(time.RFC3339Nano, o.CreatedAt); err == nil {
+			item.SetWaitingSince(createdAt) // keeps aging across the restart
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// a requeued order no longer has the robot it was matched to
+func (s *DBOrderStore) OrderQueued(ctx context.Context, orderID int) error {
+	if err := s.apply(ctx, orderID, state.StatusQueued); err != nil {
+		return err
+	}
+	return s.db.AssignOrderToRobot(ctx, int64(orderID), "")
+}
+
+func (s *DBOrderStore) OrderMatched(ctx context.Context, orderID int, robotID string) error {
+	if err := s.db.AssignOrderToRobot(ctx, int64(orderID), robotID); err != nil {
+		return err
+	}
+	return s.apply(ctx, orderID, state.StatusMatched)
+}
+
+func (s *DBOrderStore) OrderCancelled(ctx context.Context, orderID int) error {
+	return s.apply(ctx, orderID, state.StatusCancelled)
+}
+
+func (s *DBOrderStore) OrderFailed(ctx context.Context, orderID int) error {
+	return s.apply(ctx, orderID, state.StatusFailed)
+}
+
+func (s *DBOrderStore) apply(ctx context.Context, orderID int, to state.Status) error {
+	return s.states.Apply(ctx, state.Event{OrderID: int64(orderID), To: to, Source: state.SourceMatcher})
+}
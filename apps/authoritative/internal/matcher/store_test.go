@@ -0,0 +1,127 @@
+package matcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// in memory OrderStore that remembers every state change in order
+type fakeOrderStore struct {
+	mu      sync.Mutex
+	pending []*OrderItem
+	log     []string
+	stuck   chan struct{} // every write waits on it while it is open, a database that stopped answering
+}
+
+func (s *fakeOrderStore) LoadUnmatched(ctx context.Context) ([]*OrderItem, error) {
+	return s.pending, nil
+}
+
+func (s *fakeOrderStore) OrderQueued(ctx context.Context, orderID int) error {
+	s.append(fmt.Sprintf("queued %d", orderID))
+	return nil
+}
+
+func (s *fakeOrderStore) OrderMatched(ctx context.Context, orderID int, robotID string) error {
+	s.append(fmt.Sprintf("matched %d %s", orderID, robotID))
+	return nil
+}
+
+func (s *fakeOrderStore) OrderCancelled(ctx context.Context, orderID int) error {
+	s.append(fmt.Sprintf("cancelled %d", orderID))
+	return nil
+}
+
+func (s *fakeOrderStore) OrderFailed(ctx context.Context, orderID int) error {
+	s.append(fmt.Sprintf("failed %d", orderID))
+	return nil
+}
+
+func (s *fakeOrderStore) append(entry string) {
+	if s.stuck != nil {
+		<-s.stuck
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, entry)
+}
+
+func TestRestoreRequeuesUnmatchedOrders(t *testing.T) {
+	store := &fakeOrderStore{
+		pending: []*OrderItem{
+			CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, PriorityStandard),
+			CreateOrder("user", 2, 0, geo.Point{}, geo.Point{}, PriorityStandard),
+		},
+	}
+	orm := CreateOrderRobotMatcher(WithStore(store))
+	ctx, cancel := context.WithCancel(context.Background())
+	matchesChan := orm.StartORM(ctx)
+
+	restored, err := orm.Restore(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored != 2 {
+		t.Errorf("expected 2 restored orders, got %d", restored)
+	}
+
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+
+	select {
+	case match := <-matchesChan:
+		if match.OrderID != 1 {
+			t.Errorf("expected the oldest restored order to be matched, got %d", match.OrderID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match for a restored order")
+	}
+
+	cancel()
+	unmatched := orm.Wait()
+	if len(unmatched.Orders) != 1 || unmatched.Orders[0].OrderID() != 2 {
+		t.Errorf("expected order 2 to be handed back, got %v", unmatched.Orders)
+	}
+
+	expected := []string{"queued 1", "queued 2", "matched 1 robot-1"}
+	if fmt.Sprint(store.log) != fmt.Sprint(expected) {
+		t.Errorf("expected state changes %v, got %v", expected, store.log)
+	}
+}
+
+func TestSlowStoreDoesntStallMatching(t *testing.T) {
+	store := &fakeOrderStore{stuck: make(chan struct{})}
+	orm := CreateOrderRobotMatcher(WithStore(store))
+	ctx, cancel := context.WithCancel(context.Background())
+	matchesChan := orm.StartORM(ctx)
+
+	// well past anything a fixed size buffer would hold
+	const orders = 500
+	for i := 1; i <= orders; i++ {
+		orm.SubmitOrder(CreateOrder("user", i, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+	}
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+
+	select {
+	case match := <-matchesChan:
+		if match.OrderID != 1 {
+			t.Errorf("expected the oldest order to be matched, got %d", match.OrderID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a match while the store is stuck")
+	}
+
+	close(store.stuck)
+	cancel()
+	orm.Wait()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.log) != orders+1 || store.log[orders] != "matched 1 robot-1" {
+		t.Errorf("expected every event written in order once the store came back, got %d ending in %q", len(store.log), store.log[len(store.log)-1])
+	}
+}
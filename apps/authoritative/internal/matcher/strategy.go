@@ -0,0 +1,176 @@
+package matcher
+
+// strategies decide which idle robot takes which pending order, the engine just applies whatever they pick
+import (
+	"fmt"
+	"math"
+)
+
+type Assignment struct {
+	Order *OrderItem
+	Robot RobotItem
+}
+
+// orders are handed over in priority order and robots in the order they became idle,
+// strategies must not assign an order or a robot twice
+type MatchStrategy interface {
+	Name() string
+	Match(orders []*OrderItem, robots []RobotItem) []Assignment
+}
+
+func StrategyByName(name string) (MatchStrategy, error) {
+	switch name {
+	case "fifo":
+		return FIFOStrategy{}, nil
+	case "", "greedy":
+		return GreedyStrategy{}, nil
+	case "hungarian":
+		return HungarianStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown match strategy %s", name)
+}
+
+// oldest order gets the robot waiting the longest, location is ignored
+type FIFOStrategy struct{}
+
+func (FIFOStrategy) Name() string { return "fifo" }
+
+func (FIFOStrategy) Match(orders []*OrderItem, robots []RobotItem) []Assignment {
+	n := min(len(orders), len(robots))
+	assignments := make([]Assignment, 0, n)
+	for i := 0; i < n; i++ {
+		assignments = append(assignments, Assignment{Order: orders[i], Robot: robots[i]})
+	}
+	return assignments
+}
+
+// each order in priority order grabs the nearest robot that is still free
+type GreedyStrategy struct{}
+
+func (GreedyStrategy) Name() string { return "greedy" }
+
+func (GreedyStrategy) Match(orders []*OrderItem, robots []RobotItem) []Assignment {
+	taken := make([]bool, len(robots))
+	assignments := make([]Assignment, 0, min(len(orders), len(robots)))
+
+	for _, o := range orders {
+		best := -1
+		bestCost := 0.0
+		for i, r := range robots {
+			if taken[i] {
+				continue
+			}
+			cost := pickupCost(r, o)
+			if best == -1 || cost < bestCost {
+				best = i
+				bestCost = cost
+			}
+		}
+		if best == -1 { // out of robots
+			break
+		}
+		taken[best] = true
+		assignments = append(assignments, Assignment{Order: o, Robot: robots[best]})
+	}
+	return assignments
+}
+
+// minimizes the total pickup cost of the batch. when there are more orders than robots only the
+// highest priority orders are considered so that cheap orders can't starve old ones
+type HungarianStrategy struct{}
+
+func (HungarianStrategy) Name() string { return "hungarian" }
+
+func (HungarianStrategy) Match(orders []*OrderItem, robots []RobotItem) []Assignment {
+	n := min(len(orders), len(robots))
+	if n == 0 {
+		return nil
+	}
+	orders = orders[:n]
+
+	cost := make([][]float64, n)
+	for i, o := range orders {
+		cost[i] = make([]float64, len(robots))
+		for j, r := range robots {
+			cost[i][j] = pickupCost(r, o)
+		}
+	}
+
+	cols := hungarian(cost)
+	assignments := make([]Assignment, 0, n)
+	for i, o := range orders {
+		assignments = append(assignments, Assignment{Order: o, Robot: robots[cols[i]]})
+	}
+	return assignments
+}
+
+// solves the assignment problem for an n x m cost matrix with n <= m using the O(n^2 m)
+// potentials version of the hungarian algorithm, returns the column picked for each row
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	m := len(cost[0])
+
+	// 1 indexed, row/column 0 is a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1) // row matched to column j
+	way := make([]int, m+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		// walk the augmenting path back
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	cols := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			cols[p[j]-1] = j - 1
+		}
+	}
+	return cols
+}
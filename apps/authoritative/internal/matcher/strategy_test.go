@@ -0,0 +1,147 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func orderAt(orderId int, x int, y int) *OrderItem {
+	return CreateOrder("user", orderId, orderId, geo.Point{X: x, Y: y}, geo.Point{}, PriorityStandard)
+}
+
+func robotAt(robotID string, x int, y int) RobotItem {
+	return RobotItem{robotID: robotID, loc: geo.Point{X: x, Y: y}}
+}
+
+func totalCost(assignments []Assignment) float64 {
+	total := 0.0
+	for _, a := range assignments {
+		total += pickupCost(a.Robot, a.Order)
+	}
+	return total
+}
+
+func assignedRobot(t *testing.T, assignments []Assignment, orderId int) string {
+	t.Helper()
+	for _, a := range assignments {
+		if a.Order.orderId == orderId {
+			return a.Robot.robotID
+		}
+	}
+	t.Fatalf("order %d was not assigned", orderId)
+	return ""
+}
+
+func TestStrategyByName(t *testing.T) {
+	for _, name := range []string{"fifo", "greedy", "hungarian"} {
+		s, err := StrategyByName(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", name, err)
+		}
+		if s.Name() != name {
+			t.Errorf("expected strategy %s, got %s", name, s.Name())
+		}
+	}
+
+	if s, _ := StrategyByName(""); s.Name() != "greedy" {
+		t.Errorf("expected greedy as the default strategy, got %s", s.Name())
+	}
+
+	if _, err := StrategyByName("random"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestFIFOStrategyIgnoresDistance(t *testing.T) {
+	orders := []*OrderItem{orderAt(1, 0, 0), orderAt(2, 100, 100)}
+	robots := []RobotItem{robotAt("robot-a", 100, 100), robotAt("robot-b", 0, 0), robotAt("robot-c", 0, 0)}
+
+	assignments := FIFOStrategy{}.Match(orders, robots)
+
+	if len(assignments) != 2 {
+		t.Fatalf("expected 2 assignments, got %d", len(assignments))
+	}
+	if got := assignedRobot(t, assignments, 1); got != "robot-a" {
+		t.Errorf("expected order 1 to get robot-a, got %s", got)
+	}
+	if got := assignedRobot(t, assignments, 2); got != "robot-b" {
+		t.Errorf("expected order 2 to get robot-b, got %s", got)
+	}
+}
+
+func TestGreedyStrategyPicksNearestPerOrder(t *testing.T) {
+	orders := []*OrderItem{orderAt(1, 0, 0), orderAt(2, 100, 100)}
+	robots := []RobotItem{robotAt("robot-a", 100, 100), robotAt("robot-b", 1, 1)}
+
+	assignments := GreedyStrategy{}.Match(orders, robots)
+
+	if got := assignedRobot(t, assignments, 1); got != "robot-b" {
+		t.Errorf("expected order 1 to get robot-b, got %s", got)
+	}
+	if got := assignedRobot(t, assignments, 2); got != "robot-a" {
+		t.Errorf("expected order 2 to get robot-a, got %s", got)
+	}
+}
+
+func TestHungarianStrategyBeatsGreedy(t *testing.T) {
+	// greedy hands robot-a to order 1 because it is one step closer, leaving order 2 with a long trip
+	orders := []*OrderItem{orderAt(1, 0, 0), orderAt(2, 10, 0)}
+	robots := []RobotItem{robotAt("robot-a", 9, 0), robotAt("robot-b", -10, 0)}
+
+	greedy := GreedyStrategy{}.Match(orders, robots)
+	optimal := HungarianStrategy{}.Match(orders, robots)
+
+	if len(optimal) != 2 {
+		t.Fatalf("expected 2 assignments, got %d", len(optimal))
+	}
+	if got := assignedRobot(t, optimal, 1); got != "robot-b" {
+		t.Errorf("expected order 1 to get robot-b, got %s", got)
+	}
+	if got := assignedRobot(t, optimal, 2); got != "robot-a" {
+		t.Errorf("expected order 2 to get robot-a, got %s", got)
+	}
+	if totalCost(optimal) >= totalCost(greedy) {
+		t.Errorf("expected hungarian cost %.1f to beat greedy cost %.1f", totalCost(optimal), totalCost(greedy))
+	}
+}
+
+func TestHungarianStrategyServesOldestOrdersFirst(t *testing.T) {
+	// order 3 is right next to the only robot but orders 1 and 2 have waited longer
+	orders := []*OrderItem{orderAt(1, 50, 50), orderAt(2, 60, 60), orderAt(3, 0, 0)}
+	robots := []RobotItem{robotAt("robot-a", 0, 0), robotAt("robot-b", 55, 55)}
+
+	assignments := HungarianStrategy{}.Match(orders, robots)
+
+	if len(assignments) != 2 {
+		t.Fatalf("expected 2 assignments, got %d", len(assignments))
+	}
+	for _, a := range assignments {
+		if a.Order.orderId == 3 {
+			t.Error("expected order 3 to wait for a robot")
+		}
+	}
+}
+
+func TestEngineUsesConfiguredStrategy(t *testing.T) {
+	orm := CreateOrderRobotMatcher(WithStrategy(FIFOStrategy{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
+
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-far", geo.Point{X: 100, Y: 100}))
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-near", geo.Point{X: 0, Y: 0}))
+	orm.SubmitOrder(orderAt(1, 0, 0))
+
+	select {
+	case match := <-matchesChan:
+		if match.RobotID != "robot-far" {
+			t.Errorf("expected FIFO to pick robot-far, got %s", match.RobotID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected match to be created within 2 seconds")
+	}
+}
@@ -0,0 +1,122 @@
+package matcher
+
+// taking orders back off robots that already have them, the orders go back in the queue instead of being cancelled
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// a robot that already has the order on board is the only one that can deliver it
+var ErrOrderPickedUp = errors.New("order has already been picked up")
+
+type unassignRequest struct {
+	orderID int    // a single order
+	robotID string // or everything the robot is carrying
+	reason  string
+	result  chan unassignResult
+}
+
+type unassignResult struct {
+	requeued []int
+	err      error
+}
+
+// takes the order off its robot and puts it back in the queue, keeping the wait it already built up
+// reports false if no robot had it, an order that was already picked up stays with its robot and ErrOrderPickedUp is returned
+func (orm *OrderRobotMatcher) UnassignOrder(orderID int, reason string) (bool, error) {
+	requeued, err := orm.unassign(&unassignRequest{orderID: orderID, reason: reason})
+	return len(requeued) > 0, err
+}
+
+// takes every order off the robot and sends it back, returns the orders that went back in the queue
+// orders the robot already picked up can't be delivered by anyone else, they are failed instead
+func (orm *OrderRobotMatcher) RecallRobot(robotID string, reason string) ([]int, error) {
+	return orm.unassign(&unassignRequest{robotID: robotID, reason: reason})
+}
+
+func (orm *OrderRobotMatcher) unassign(req *unassignRequest) ([]int, error) {
+	req.result = make(chan unassignResult, 1)
+
+	select {
+	case orm.unassignIntake <- req:
+	case <-orm.done:
+		return nil, ErrMatcherStopped
+	}
+
+	select {
+	case result := <-req.result:
+		return result.requeued, result.err
+	case <-orm.done:
+		return nil, ErrMatcherStopped
+	}
+}
+
+func (orm *OrderRobotMatcher) applyUnassign(ctx context.Context, req *unassignRequest, matchesChan chan (*OrderRobotMatch)) unassignResult {
+	var orderIDs []int
+	for orderID, a := range orm.active {
+		if (req.robotID == "" && orderID == req.orderID) || (req.robotID != "" && a.robot.robotID == req.robotID) {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	slices.Sort(orderIDs)
+
+	var result unassignResult
+	var failed []int
+	robotIDs := map[string]bool{}
+	for _, orderID := range orderIDs {
+		a := orm.active[orderID]
+		pickedUp := orm.pickedUp(ctx, orderID)
+		if pickedUp && req.robotID == "" {
+			result.err = fmt.Errorf("order %d is on robot %s: %w", orderID, a.robot.robotID, ErrOrderPickedUp)
+			continue
+		}
+
+		select {
+		case matchesChan <- &OrderRobotMatch{OrderID: orderID, RobotID: a.robot.robotID, Recall: true, Reason: req.reason}:
+		case <-ctx.Done():
+			return result
+		}
+
+		delete(orm.active, orderID)
+		robotIDs[a.robot.robotID] = true
+		if pickedUp {
+			orm.record(orderEvent{kind: orderFailed, orderID: orderID})
+			failed = append(failed, orderID)
+			continue
+		}
+		orm.queueOrder(a.order)
+		result.requeued = append(result.requeued, orderID)
+	}
+
+	// unlike a cancel the robot isn't queued again right away, it would likely just get the same orders back
+	// it rejoins once it reports in
+	for robotID := range robotIDs {
+		if !orm.carrying(robotID) {
+			orm.recallRobot(robotID, req.reason)
+		}
+	}
+
+	if len(result.requeued) > 0 {
+		log.Printf("orders %v taken off their robot and requeued: %s\n", result.requeued, req.reason)
+	}
+	if len(failed) > 0 {
+		log.Printf("orders %v were already picked up, failed them: %s\n", failed, req.reason)
+	}
+	return result
+}
+
+// whether the robot already has the order on board, an order whose status can't be read is treated as not
+func (orm *OrderRobotMatcher) pickedUp(ctx context.Context, orderID int) bool {
+	if orm.statuses == nil {
+		return false
+	}
+	status, err := orm.statuses.Status(ctx, int64(orderID))
+	if err != nil {
+		log.Printf("failed checking order %d before taking it off its robot: %v\n", orderID, err)
+		return false
+	}
+	return status.PickedUp()
+}
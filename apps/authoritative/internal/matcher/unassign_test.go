@@ -0,0 +1,154 @@
+package matcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func TestRecallRobotRequeuesItsOrders(t *testing.T) {
+	fleet := robots.NewManager()
+	orm := CreateOrderRobotMatcher(WithRobots(fleet))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matchesChan := orm.StartORM(ctx)
+
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	orm.SubmitOrder(CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, PriorityStandard))
+
+	select {
+	case <-matchesChan:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a match before recalling")
+	}
+
+	requeued, err := orm.RecallRobot("robot-1", "test recall")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requeued) != 1 || requeued[0] != 1 {
+		t.Errorf("expected order 1 to be requeued, got %v", requeued)
+	}
+
+	select {
+	case recall := <-matchesChan:
+		if !recall.Recall || recall.OrderID != 1 || recall.Reason != "test recall" {
+			t.Errorf("expected a recall of order 1, got %+v", recall)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a recall to be sent")
+	}
+	if got := fleet.State("robot-1"); got != robots.StateIdle {
+		t.Errorf("expected robot-1 to be idle after a recall before it moved, got %s", got)
+	}
+
+	// the order is waiting again and goes to the next robot that shows up
+	fleet.Transition("robot-2", robots.StateIdle, "test")
+	orm.SubmitRobot(NewRobotUpdate(robots.StateIdle, "robot-2", geo.Point{}))
+	select {
+	case match := <-matchesChan:
+		if match.OrderID != 1 || match.RobotID != "robot-2" {
+			t.Errorf("expected order 1 to go to robot-2, got %+v", match)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the requeued order to be matched again")
+	}
+}
+
+func TestUnassignOrderLeavesTheRestOfTheRoute(t *testing.T) {
+	orm := CreateOrderRobotMatcher()
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 10, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 12, Y: 0}))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1", compartments: 2, free: 2})
+	orm.attemptMatch(context.Background(), matchesChan)
+	<-matchesChan
+
+	requeued := orm.applyUnassign(context.Background(), &unassignRequest{orderID: 2}, matchesChan).requeued
+	if len(requeued) != 1 || requeued[0] != 2 {
+		t.Fatalf("expected only order 2 to be requeued, got %v", requeued)
+	}
+	if _, ok := orm.active[1]; !ok {
+		t.Error("expected order 1 to stay out with robot-1")
+	}
+	if orm.orderQueue.Len() != 1 {
+		t.Errorf("expected order 2 back in the queue, %d queued", orm.orderQueue.Len())
+	}
+
+	if requeued := orm.applyUnassign(context.Background(), &unassignRequest{orderID: 2}, matchesChan).requeued; len(requeued) != 0 {
+		t.Errorf("expected nothing to unassign for a queued order, got %v", requeued)
+	}
+}
+
+type fakeStatuses map[int64]state.Status
+
+func (f fakeStatuses) Status(ctx context.Context, orderID int64) (state.Status, error) {
+	return f[orderID], nil
+}
+
+func TestPickedUpOrdersStayOffTheQueue(t *testing.T) {
+	statuses := fakeStatuses{1: state.StatusPickedUp, 2: state.StatusMatched}
+	orm := CreateOrderRobotMatcher(WithStore(&fakeOrderStore{}), WithStatuses(statuses))
+	matchesChan := make(chan *OrderRobotMatch, 10)
+
+	vendor := geo.Point{X: 0, Y: 0}
+	orm.orderQueue.Insert(dropoffOrder(1, vendor, geo.Point{X: 10, Y: 0}))
+	orm.orderQueue.Insert(dropoffOrder(2, vendor, geo.Point{X: 12, Y: 0}))
+	orm.robotQueue.Enqueue(RobotItem{robotID: "robot-1", compartments: 2, free: 2})
+	orm.attemptMatch(context.Background(), matchesChan)
+	<-matchesChan
+	orm.events.take() // only what the recall records matters
+
+	// an operator can't hand an order that's on board to someone else
+	result := orm.applyUnassign(context.Background(), &unassignRequest{orderID: 1}, matchesChan)
+	if !errors.Is(result.err, ErrOrderPickedUp) || len(result.requeued) != 0 {
+		t.Errorf("expected the unassign to be refused, got %+v", result)
+	}
+	if _, ok := orm.active[1]; !ok {
+		t.Error("expected order 1 to stay with robot-1")
+	}
+
+	// recalling the robot fails what it has on board and requeues the rest
+	result = orm.applyUnassign(context.Background(), &unassignRequest{robotID: "robot-1"}, matchesChan)
+	if result.err != nil || len(result.requeued) != 1 || result.requeued[0] != 2 {
+		t.Errorf("expected only order 2 to be requeued, got %+v", result)
+	}
+	if orm.orderQueue.Len() != 1 || len(orm.active) != 0 {
+		t.Errorf("expected only order 2 queued and nothing out, %d queued %d out", orm.orderQueue.Len(), len(orm.active))
+	}
+	events, _ := orm.events.take()
+	if len(events) != 2 || events[0] != (orderEvent{kind: orderFailed, orderID: 1}) || events[1] != (orderEvent{kind: orderQueued, orderID: 2}) {
+		t.Errorf("expected order 1 failed and order 2 queued, got %+v", events)
+	}
+	for range 2 {
+		if recall := <-matchesChan; !recall.Recall {
+			t.Errorf("expected robot-1 to be told to drop both orders, got %+v", recall)
+		}
+	}
+}
+
+func TestDrainingRobotIsNotQueued(t *testing.T) {
+	fleet := robots.NewManager()
+	orm := CreateOrderRobotMatcher(WithRobots(fleet))
+
+	fleet.Transition("robot-1", robots.StateIdle, "test")
+	fleet.SetDraining("robot-1", true)
+	orm.applyRobotUpdate(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	if orm.robotQueue.Has("robot-1") {
+		t.Error("expected a draining robot to stay out of the queue")
+	}
+
+	fleet.SetDraining("robot-1", false)
+	orm.applyRobotUpdate(NewRobotUpdate(robots.StateIdle, "robot-1", geo.Point{}))
+	if !orm.robotQueue.Has("robot-1") {
+		t.Error("expected the robot to be queued once resumed")
+	}
+}
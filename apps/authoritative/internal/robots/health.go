@@ -0,0 +1,95 @@
+package robots
+
+// robots that turn down or never answer an assignment are kept off new routes for a while, every attempt is kept so operators can see why
+import "time"
+
+// how many assignment attempts are kept per robot
+const DefaultAttemptLimit = 50
+
+type AttemptOutcome int
+
+const (
+	AttemptAccepted    AttemptOutcome = iota
+	AttemptRejected                   // the robot said no
+	AttemptTimedOut                   // no ack before the deadline
+	AttemptUndelivered                // the robot was gone or too far behind to be sent the route
+)
+
+var attemptOutcomeNames = map[AttemptOutcome]string{
+	AttemptAccepted:    "accepted",
+	AttemptRejected:    "rejected",
+	AttemptTimedOut:    "timed_out",
+	AttemptUndelivered: "undelivered",
+}
+
+func (o AttemptOutcome) String() string {
+	if name, ok := attemptOutcomeNames[o]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// one route handed to a robot and what came of it
+type Attempt struct {
+	OrderIDs []int
+	At       time.Time
+	Outcome  AttemptOutcome
+	Reason   string
+}
+
+func (m *Manager) RecordAttempt(robotID string, orderIDs []int, outcome AttemptOutcome, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		r = &robot{state: StateOffline, since: m.now()}
+		m.robots[robotID] = r
+	}
+	r.attempts = append(r.attempts, Attempt{
+		OrderIDs: append([]int(nil), orderIDs...),
+		At:       m.now(),
+		Outcome:  outcome,
+		Reason:   reason,
+	})
+	if len(r.attempts) > DefaultAttemptLimit {
+		r.attempts = r.attempts[len(r.attempts)-DefaultAttemptLimit:]
+	}
+}
+
+func (m *Manager) Attempts(robotID string) []Attempt {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		return nil
+	}
+	return append([]Attempt(nil), r.attempts...)
+}
+
+// Assign turns the robot down until then, marking it again only ever pushes the time out
+func (m *Manager) MarkUnhealthy(robotID string, until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		r = &robot{state: StateOffline, since: m.now()}
+		m.robots[robotID] = r
+	}
+	if until.After(r.unhealthyUntil) {
+		r.unhealthyUntil = until
+	}
+}
+
+func (m *Manager) Healthy(robotID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.healthyLocked(robotID)
+}
+
+func (m *Manager) healthyLocked(robotID string) bool {
+	r, ok := m.robots[robotID]
+	return !ok || !m.now().Before(r.unhealthyUntil)
+}
@@ -0,0 +1,47 @@
+package robots
+
+// what robots last said in their heartbeats, a robot that stops sending them is stale and gets no new routes
+import "time"
+
+// records a heartbeat, a stale robot is fresh again
+func (m *Manager) Heartbeat(robotID string, battery int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		r = &robot{state: StateOffline, since: m.now()}
+		m.robots[robotID] = r
+	}
+	r.battery = battery
+	r.lastHeartbeat = m.now()
+	r.stale = false
+}
+
+// missed its heartbeats but hasn't been given up on yet
+func (m *Manager) SetStale(robotID string, stale bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.robots[robotID]; ok {
+		r.stale = stale
+	}
+}
+
+func (m *Manager) Stale(robotID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.robots[robotID]
+	return ok && r.stale
+}
+
+func (m *Manager) LastHeartbeat(robotID string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if r, ok := m.robots[robotID]; ok {
+		return r.lastHeartbeat
+	}
+	return time.Time{}
+}
@@ -1 +1,181 @@
 package robots
+
+// single source of truth for what every robot is doing, the hub and matcher both go through here
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrInvalidTransition = errors.New("invalid robot state transition")
+
+// how many transitions are kept per robot
+const DefaultHistoryLimit = 100
+
+type robot struct {
+	state    State
+	since    time.Time
+	history  []Transition
+	draining bool
+
+	unhealthyUntil time.Time // kept off new routes until then
+	attempts       []Attempt
+
+	stale         bool
+	battery       int // percent, from the last heartbeat
+	lastHeartbeat time.Time
+}
+
+type Manager struct {
+	mu           sync.RWMutex
+	robots       map[string]*robot
+	historyLimit int
+	now          func() time.Time
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		robots:       make(map[string]*robot),
+		historyLimit: DefaultHistoryLimit,
+		now:          time.Now,
+	}
+}
+
+// moves the robot to a new state, robots we haven't seen yet start out offline
+// reporting the state the robot is already in is fine and isn't recorded
+func (m *Manager) Transition(robotID string, to State, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.transition(robotID, to, reason)
+}
+
+// claims a free robot for a route, fails if something else got to it first or it went away
+func (m *Manager) Assign(robotID string, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state := m.stateLocked(robotID); !state.Available() {
+		return fmt.Errorf("%w: robot %s is %s and can't take a route", ErrInvalidTransition, robotID, state)
+	}
+	if r, ok := m.robots[robotID]; ok && r.draining {
+		return fmt.Errorf("%w: robot %s is draining", ErrInvalidTransition, robotID)
+	}
+	if !m.healthyLocked(robotID) {
+		return fmt.Errorf("%w: robot %s is unhealthy", ErrInvalidTransition, robotID)
+	}
+	if r, ok := m.robots[robotID]; ok && r.stale {
+		return fmt.Errorf("%w: robot %s stopped sending heartbeats", ErrInvalidTransition, robotID)
+	}
+	return m.transition(robotID, StateAssigned, reason)
+}
+
+// a draining robot finishes what it is carrying but Assign turns it down until it is resumed
+func (m *Manager) SetDraining(robotID string, draining bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		r = &robot{state: StateOffline, since: m.now()}
+		m.robots[robotID] = r
+	}
+	r.draining = draining
+}
+
+func (m *Manager) Draining(robotID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.robots[robotID]
+	return ok && r.draining
+}
+
+func (m *Manager) transition(robotID string, to State, reason string) error {
+	r, ok := m.robots[robotID]
+	if !ok {
+		r = &robot{state: StateOffline, since: m.now()}
+		m.robots[robotID] = r
+	}
+
+	if r.state == to {
+		return nil
+	}
+	if !CanTransition(r.state, to) {
+		return fmt.Errorf("%w: robot %s %s -> %s", ErrInvalidTransition, robotID, r.state, to)
+	}
+
+	now := m.now()
+	r.history = append(r.history, Transition{From: r.state, To: to, At: now, Reason: reason})
+	if len(r.history) > m.historyLimit {
+		r.history = r.history[len(r.history)-m.historyLimit:]
+	}
+	r.state = to
+	r.since = now
+	return nil
+}
+
+// unknown robots are offline
+func (m *Manager) State(robotID string) State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stateLocked(robotID)
+}
+
+func (m *Manager) stateLocked(robotID string) State {
+	if r, ok := m.robots[robotID]; ok {
+		return r.state
+	}
+	return StateOffline
+}
+
+func (m *Manager) Get(robotID string) (Robot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		return Robot{}, false
+	}
+	return r.snapshot(robotID), true
+}
+
+func (m *Manager) History(robotID string) []Transition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.robots[robotID]
+	if !ok {
+		return nil
+	}
+	return append([]Transition(nil), r.history...)
+}
+
+// every robot the manager has seen, without their history
+func (m *Manager) Robots() []Robot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]Robot, 0, len(m.robots))
+	for id, r := range m.robots {
+		out = append(out, Robot{ID: id, State: r.state, Since: r.since, Draining: r.draining, UnhealthyUntil: r.unhealthyUntil, Stale: r.stale, Battery: r.battery, LastHeartbeat: r.lastHeartbeat})
+	}
+	return out
+}
+
+func (r *robot) snapshot(id string) Robot {
+	return Robot{
+		ID:       id,
+		State:    r.state,
+		Since:    r.since,
+		History:  append([]Transition(nil), r.history...),
+		Draining: r.draining,
+
+		UnhealthyUntil: r.unhealthyUntil,
+		Attempts:       append([]Attempt(nil), r.attempts...),
+
+		Stale:         r.stale,
+		Battery:       r.battery,
+		LastHeartbeat: r.lastHeartbeat,
+	}
+}
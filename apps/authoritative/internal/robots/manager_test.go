@@ -0,0 +1,144 @@
+package robots
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerFollowsDeliveryCycle(t *testing.T) {
+	m := NewManager()
+
+	cycle := []State{
+		StateIdle,
+		StateAssigned,
+		StateEnRouteToPickup,
+		StateLoading,
+		StateEnRouteToDropoff,
+		StateDelivering,
+		StateEnRouteToDropoff, // second order on the route
+		StateDelivering,
+		StateReturning,
+		StateIdle,
+	}
+	for _, s := range cycle {
+		if err := m.Transition("robot-1", s, "test"); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", s, err)
+		}
+	}
+
+	if got := m.State("robot-1"); got != StateIdle {
+		t.Errorf("expected robot to be idle, got %s", got)
+	}
+	if got := len(m.History("robot-1")); got != len(cycle) {
+		t.Errorf("expected %d transitions in history, got %d", len(cycle), got)
+	}
+}
+
+func TestManagerRejectsInvalidTransition(t *testing.T) {
+	m := NewManager()
+	m.Transition("robot-1", StateIdle, "test")
+
+	err := m.Transition("robot-1", StateDelivering, "test")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if got := m.State("robot-1"); got != StateIdle {
+		t.Errorf("expected a rejected transition to leave the robot idle, got %s", got)
+	}
+	if got := len(m.History("robot-1")); got != 1 {
+		t.Errorf("expected rejected transitions to stay out of the history, got %d entries", got)
+	}
+}
+
+func TestManagerAnyStateCanFaultOrDrop(t *testing.T) {
+	m := NewManager()
+	for _, s := range []State{StateIdle, StateAssigned, StateEnRouteToPickup, StateLoading} {
+		if err := m.Transition("robot-1", s, "test"); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", s, err)
+		}
+	}
+
+	if err := m.Transition("robot-1", StateFaulted, "motor stalled"); err != nil {
+		t.Errorf("expected a loaded robot to be able to fault, got %v", err)
+	}
+	if err := m.Transition("robot-1", StateOffline, "disconnected"); err != nil {
+		t.Errorf("expected a faulted robot to be able to drop off, got %v", err)
+	}
+}
+
+func TestManagerAssignOnlyClaimsFreeRobots(t *testing.T) {
+	m := NewManager()
+
+	if err := m.Assign("robot-1", "order 1"); err == nil {
+		t.Error("expected an unknown robot not to be assignable")
+	}
+
+	m.Transition("robot-1", StateIdle, "test")
+	if err := m.Assign("robot-1", "order 1"); err != nil {
+		t.Fatalf("unexpected error assigning an idle robot: %v", err)
+	}
+	if err := m.Assign("robot-1", "order 2"); err == nil {
+		t.Error("expected an assigned robot not to be claimed twice")
+	}
+}
+
+func TestParseStateAcceptsLegacyNames(t *testing.T) {
+	for name, expected := range map[string]State{
+		"online":     StateIdle,
+		"shutdown":   StateOffline,
+		"to_dropoff": StateEnRouteToDropoff,
+	} {
+		got, err := ParseState(name)
+		if err != nil || got != expected {
+			t.Errorf("ParseState(%q) = %s, %v, expected %s", name, got, err, expected)
+		}
+	}
+
+	if _, err := ParseState("flying"); err == nil {
+		t.Error("expected an error for an unknown state")
+	}
+}
+
+func TestDrainingRobotIsNotAssigned(t *testing.T) {
+	m := NewManager()
+	m.Transition("robot-1", StateIdle, "test")
+	m.SetDraining("robot-1", true)
+
+	if err := m.Assign("robot-1", "test"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a draining robot to be turned down, got %v", err)
+	}
+
+	m.SetDraining("robot-1", false)
+	if err := m.Assign("robot-1", "test"); err != nil {
+		t.Errorf("expected the robot to take routes again once resumed, got %v", err)
+	}
+}
+
+func TestUnhealthyRobotSitsOutItsCooldown(t *testing.T) {
+	now := time.Now()
+	m := NewManager()
+	m.now = func() time.Time { return now }
+	m.Transition("robot-1", StateIdle, "test")
+
+	m.RecordAttempt("robot-1", []int{1}, AttemptTimedOut, "no ack")
+	m.MarkUnhealthy("robot-1", now.Add(time.Minute))
+	m.MarkUnhealthy("robot-1", now.Add(time.Second)) // doesn't shorten it
+
+	if err := m.Assign("robot-1", "test"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected an unhealthy robot to be turned down, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if !m.Healthy("robot-1") {
+		t.Error("expected the robot to be healthy once the cooldown is over")
+	}
+	if err := m.Assign("robot-1", "test"); err != nil {
+		t.Errorf("expected the robot to take routes again, got %v", err)
+	}
+
+	attempts := m.Attempts("robot-1")
+	if len(attempts) != 1 || attempts[0].Outcome != AttemptTimedOut || attempts[0].OrderIDs[0] != 1 {
+		t.Errorf("expected the timed out attempt to be recorded, got %+v", attempts)
+	}
+}
@@ -1,8 +1,121 @@
 package robots
 
-import "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/wsockets"
+// robot states and which moves between them are allowed
+import (
+	"fmt"
+	"time"
+)
 
-type RobotStage struct {
-	robotID int
-	client  *wsockets.Client
+type State int
+
+const (
+	StateOffline State = iota
+	StateIdle
+	StateAssigned
+	StateEnRouteToPickup
+	StateLoading
+	StateEnRouteToDropoff
+	StateDelivering
+	StateReturning
+	StateFaulted
+)
+
+// names robots use on the wire
+var stateNames = map[State]string{
+	StateOffline:          "offline",
+	StateIdle:             "idle",
+	StateAssigned:         "assigned",
+	StateEnRouteToPickup:  "to_pickup",
+	StateLoading:          "loading",
+	StateEnRouteToDropoff: "to_dropoff",
+	StateDelivering:       "delivering",
+	StateReturning:        "returning",
+	StateFaulted:          "faulted",
+}
+
+// what the robots sent before there were states, still accepted
+var legacyNames = map[string]State{
+	"online":   StateIdle,
+	"shutdown": StateOffline,
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+func ParseState(name string) (State, error) {
+	for s, n := range stateNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	if s, ok := legacyNames[name]; ok {
+		return s, nil
+	}
+	return StateOffline, fmt.Errorf("unknown robot state %q", name)
+}
+
+// whether the matcher can hand the robot a new route
+func (s State) Available() bool {
+	return s == StateIdle || s == StateReturning
+}
+
+// whether the robot has a route it hasn't finished, on its way to the vendor or with orders on board
+func (s State) OnRoute() bool {
+	switch s {
+	case StateAssigned, StateEnRouteToPickup, StateLoading, StateEnRouteToDropoff, StateDelivering:
+		return true
+	}
+	return false
+}
+
+// a robot can always drop off the network or fault, these are the moves on top of that
+var transitions = map[State][]State{
+	StateOffline:          {StateIdle},
+	StateIdle:             {StateAssigned},
+	StateAssigned:         {StateEnRouteToPickup, StateIdle}, // idle again when recalled before it moved
+	StateEnRouteToPickup:  {StateLoading, StateReturning},    // returning when recalled
+	StateLoading:          {StateEnRouteToDropoff, StateReturning},
+	StateEnRouteToDropoff: {StateDelivering, StateReturning},
+	StateDelivering:       {StateEnRouteToDropoff, StateReturning, StateIdle}, // next stop on a batched route, or done
+	StateReturning:        {StateIdle, StateAssigned},
+	StateFaulted:          {StateIdle},
+}
+
+func CanTransition(from State, to State) bool {
+	if to == StateOffline || to == StateFaulted {
+		return from != to
+	}
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Transition struct {
+	From   State
+	To     State
+	At     time.Time
+	Reason string
+}
+
+// copy of a robot's state handed out by the manager
+type Robot struct {
+	ID       string
+	State    State
+	Since    time.Time
+	History  []Transition
+	Draining bool // turned away from new routes
+
+	UnhealthyUntil time.Time // turned away from new routes until then, zero if it never was
+	Attempts       []Attempt
+
+	Stale         bool // missed its heartbeats, turned away from new routes until the next one
+	Battery       int
+	LastHeartbeat time.Time
 }
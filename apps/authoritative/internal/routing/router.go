@@ -1,3 +1,50 @@
 package routing
 
 // routing logic, estimation, ETA calculations
+import (
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// for robots that don't report their speed, in grid cells per second
+const DefaultSpeed = 1.0
+
+// where robots are and how fast they are going, the live telemetry store
+type Live interface {
+	Position(robotID string) (geo.Point, bool)
+	Speed(robotID string) (float64, bool)
+}
+
+// straight line for now, the campus grid has no obstacles yet
+func TravelTime(from geo.Point, to geo.Point, speed float64) time.Duration {
+	if speed <= 0 {
+		speed = DefaultSpeed
+	}
+	return time.Duration(geo.Distance(from, to) / speed * float64(time.Second))
+}
+
+type Estimator struct {
+	live Live
+}
+
+func NewEstimator(live Live) *Estimator {
+	return &Estimator{live: live}
+}
+
+// how long the robot needs to get through the stops in order from where it last said it was
+// false if it hasn't reported a position lately
+func (e *Estimator) ETA(robotID string, stops ...geo.Point) (time.Duration, bool) {
+	at, ok := e.live.Position(robotID)
+	if !ok {
+		return 0, false
+	}
+	speed, _ := e.live.Speed(robotID)
+
+	var eta time.Duration
+	for _, stop := range stops {
+		eta += TravelTime(at, stop, speed)
+		at = stop
+	}
+	return eta, true
+}
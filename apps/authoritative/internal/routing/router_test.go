@@ -0,0 +1,28 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func TestETAGoesThroughEveryStop(t *testing.T) {
+	live := telemetry.NewStore()
+	live.Record(telemetry.Reading{RobotID: "fast", Loc: geo.Point{X: 0, Y: 0}, Speed: 2})
+	live.Record(telemetry.Reading{RobotID: "unknown-speed", Loc: geo.Point{X: 0, Y: 0}})
+	e := NewEstimator(live)
+
+	// 3-4-5 to the vendor then 10 along to the drop-off
+	stops := []geo.Point{{X: 3, Y: 4}, {X: 13, Y: 4}}
+	if eta, ok := e.ETA("fast", stops...); !ok || eta != 7500*time.Millisecond {
+		t.Errorf("expected 7.5s, got %s %v", eta, ok)
+	}
+	if eta, ok := e.ETA("unknown-speed", stops...); !ok || eta != 15*time.Second {
+		t.Errorf("expected 15s at the default speed, got %s %v", eta, ok)
+	}
+	if _, ok := e.ETA("never-reported", stops...); ok {
+		t.Error("expected no eta for a robot without a position")
+	}
+}
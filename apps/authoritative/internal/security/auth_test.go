@@ -0,0 +1,108 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+)
+
+var testSecret = []byte("test-secret-that-is-long-enough-for-hmac")
+
+func newTestAuth(t *testing.T, opts ...AuthOption) (*RobotAuth, *db.MemoryStore) {
+	t.Helper()
+	store := db.NewMemoryStore()
+	store.InsertRobot(context.Background(), db.Robot{ID: "robot-1", Status: db.RobotStatusActive})
+
+	auth, err := NewRobotAuth(testSecret, store, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return auth, store
+}
+
+func TestNewRobotAuthRejectsShortSecret(t *testing.T) {
+	if _, err := NewRobotAuth([]byte("short"), db.NewMemoryStore()); err == nil {
+		t.Error("expected a short secret to be rejected")
+	}
+}
+
+func TestIssuedTokenVerifies(t *testing.T) {
+	auth, _ := newTestAuth(t)
+
+	token, expires, err := auth.Issue("robot-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !expires.After(time.Now()) {
+		t.Errorf("expected the token to expire in the future, got %v", expires)
+	}
+
+	robotID, err := auth.Verify(token)
+	if err != nil || robotID != "robot-1" {
+		t.Errorf("expected the token to verify as robot-1, got %q (%v)", robotID, err)
+	}
+}
+
+func TestTamperedTokenIsRejected(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	token, _, _ := auth.Issue("robot-1")
+
+	// same signature, someone else's id
+	spoofed := "robot-2" + strings.TrimPrefix(token, "robot-1")
+	if _, err := auth.Verify(spoofed); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for a spoofed id, got %v", err)
+	}
+
+	other, _ := NewRobotAuth([]byte("another-secret-that-is-long-enough-too"), db.NewMemoryStore())
+	forged, _, _ := other.Issue("robot-1")
+	if _, err := auth.Verify(forged); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for a token signed with another secret, got %v", err)
+	}
+
+	if _, err := auth.Verify("garbage"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for garbage, got %v", err)
+	}
+}
+
+func TestExpiredTokenIsRejected(t *testing.T) {
+	auth, _ := newTestAuth(t, WithTokenTTL(time.Minute))
+	token, _, _ := auth.Issue("robot-1")
+
+	auth.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := auth.Verify(token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestAuthenticateChecksTheRobotsTable(t *testing.T) {
+	auth, store := newTestAuth(t)
+	token, _, _ := auth.Issue("robot-1")
+
+	r := httptest.NewRequest("GET", "/ws", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	if robotID, err := auth.Authenticate(r); err != nil || robotID != "robot-1" {
+		t.Errorf("expected robot-1 to get in with a bearer token, got %q (%v)", robotID, err)
+	}
+
+	r = httptest.NewRequest("GET", "/ws?token="+token, nil)
+	if robotID, err := auth.Authenticate(r); err != nil || robotID != "robot-1" {
+		t.Errorf("expected robot-1 to get in with a query token, got %q (%v)", robotID, err)
+	}
+
+	r = httptest.NewRequest("GET", "/ws", nil)
+	if _, err := auth.Authenticate(r); !errors.Is(err, ErrMissingToken) {
+		t.Errorf("expected ErrMissingToken, got %v", err)
+	}
+
+	// taking the robot out of the table locks it out
+	store.DeleteRobot(context.Background(), "robot-1")
+	r = httptest.NewRequest("GET", "/ws?token="+token, nil)
+	if _, err := auth.Authenticate(r); !errors.Is(err, ErrUnknownRobot) {
+		t.Errorf("expected ErrUnknownRobot once deleted, got %v", err)
+	}
+}
@@ -1,3 +1,145 @@
 package state
 
-// in memory register of robot states
+// in memory register of order statuses, every change is checked here and written through to the database
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+)
+
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// the parts of the database the manager needs, *db.Database satisfies it
+type StatusStore interface {
+	GetOrder(ctx context.Context, id int64) (db.Order, error)
+	UpdateOrderStatus(ctx context.Context, id int64, status string) error
+}
+
+type Manager struct {
+	mu     sync.Mutex // guards orders and locks, never held across a call to the store
+	store  StatusStore
+	orders map[int64]Status // orders that aren't done yet, terminal ones are dropped once written
+	locks  map[int64]*orderLock
+	watch  *watchers
+}
+
+// held across the write so two events for an order land in the order they were applied, other orders don't wait on it
+type orderLock struct {
+	mu    sync.Mutex
+	users int // callers holding or waiting on it, it is dropped at zero
+}
+
+func NewManager(store StatusStore) *Manager {
+	return &Manager{
+		store:  store,
+		orders: make(map[int64]Status),
+		locks:  make(map[int64]*orderLock),
+		watch:  newWatchers(),
+	}
+}
+
+// takes the order's lock, the returned func lets go of it
+func (m *Manager) lock(orderID int64) func() {
+	m.mu.Lock()
+	l, ok := m.locks[orderID]
+	if !ok {
+		l = &orderLock{}
+		m.locks[orderID] = l
+	}
+	l.users++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		l.users--
+		if l.users == 0 {
+			delete(m.locks, orderID)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// records an order that was just inserted, it starts out created without another write
+func (m *Manager) Created(orderID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[orderID] = StatusCreated
+}
+
+// forgets an order that was deleted from the database
+func (m *Manager) Deleted(orderID int64) {
+	unlock := m.lock(orderID)
+	defer unlock()
+
+	m.mu.Lock()
+	delete(m.orders, orderID)
+	m.mu.Unlock()
+}
+
+// moves an order to ev.To if the lifecycle allows it, applying the status it already has is a no-op
+func (m *Manager) Apply(ctx context.Context, ev Event) error {
+	unlock := m.lock(ev.OrderID)
+	defer unlock()
+
+	current, err := m.statusLocked(ctx, ev.OrderID)
+	if err != nil {
+		return err
+	}
+
+	if current == ev.To {
+		return nil
+	}
+	if !CanTransition(current, ev.To) {
+		return fmt.Errorf("%w: order %d %s -> %s from %s", ErrInvalidTransition, ev.OrderID, current, ev.To, ev.Source)
+	}
+
+	if err := m.store.UpdateOrderStatus(ctx, ev.OrderID, string(ev.To)); err != nil {
+		return err
+	}
+	m.remember(ev.OrderID, ev.To)
+	m.watch.statusChanged(ev.OrderID, ev.To)
+	log.Printf("order %d %s -> %s (%s)\n", ev.OrderID, current, ev.To, ev.Source)
+	return nil
+}
+
+func (m *Manager) Status(ctx context.Context, orderID int64) (Status, error) {
+	unlock := m.lock(orderID)
+	defer unlock()
+	return m.statusLocked(ctx, orderID)
+}
+
+// call with the order's lock held
+// orders from before a restart, or already done, aren't in memory, the database has the last status we wrote
+func (m *Manager) statusLocked(ctx context.Context, orderID int64) (Status, error) {
+	m.mu.Lock()
+	s, ok := m.orders[orderID]
+	m.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	o, err := m.store.GetOrder(ctx, orderID)
+	if err != nil {
+		return "", err
+	}
+	s = Status(o.Status)
+	m.remember(orderID, s)
+	return s, nil
+}
+
+// nothing happens to a terminal order anymore, keeping it around would only grow the map
+func (m *Manager) remember(orderID int64, s Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.Terminal() {
+		delete(m.orders, orderID)
+		return
+	}
+	m.orders[orderID] = s
+}
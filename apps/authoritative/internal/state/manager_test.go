@@ -0,0 +1,162 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+)
+
+type fakeStatusStore struct {
+	mu     sync.Mutex
+	rows   map[int64]string
+	writes []string
+	stuck  map[int64]chan struct{} // writes for the order wait on it while it is open
+}
+
+func newFakeStatusStore() *fakeStatusStore {
+	return &fakeStatusStore{rows: make(map[int64]string)}
+}
+
+func (f *fakeStatusStore) GetOrder(ctx context.Context, id int64) (db.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.rows[id]
+	if !ok {
+		return db.Order{}, errors.New("no such order")
+	}
+	return db.Order{ID: id, Status: status}, nil
+}
+
+func (f *fakeStatusStore) UpdateOrderStatus(ctx context.Context, id int64, status string) error {
+	f.mu.Lock()
+	stuck := f.stuck[id]
+	f.mu.Unlock()
+	if stuck != nil {
+		<-stuck
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows[id] = status
+	f.writes = append(f.writes, status)
+	return nil
+}
+
+func TestManagerWritesEveryTransition(t *testing.T) {
+	store := newFakeStatusStore()
+	m := NewManager(store)
+	m.Created(1)
+
+	lifecycle := []Status{StatusQueued, StatusMatched, StatusPickedUp, StatusInTransit, StatusArrived, StatusDelivered}
+	for _, s := range lifecycle {
+		if err := m.Apply(context.Background(), Event{OrderID: 1, To: s, Source: SourceRobot}); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", s, err)
+		}
+	}
+
+	if len(store.writes) != len(lifecycle) {
+		t.Fatalf("expected %d writes, got %d", len(lifecycle), len(store.writes))
+	}
+	if Status(store.rows[1]) != StatusDelivered {
+		t.Errorf("expected the order to be stored as delivered, got %s", Status(store.rows[1]))
+	}
+}
+
+func TestManagerRejectsIllegalTransition(t *testing.T) {
+	store := newFakeStatusStore()
+	m := NewManager(store)
+	m.Created(1)
+
+	err := m.Apply(context.Background(), Event{OrderID: 1, To: StatusDelivered, Source: SourceRobot})
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if len(store.writes) != 0 {
+		t.Errorf("expected nothing written, got %v", store.writes)
+	}
+}
+
+func TestManagerTerminalStatusesStay(t *testing.T) {
+	store := newFakeStatusStore()
+	m := NewManager(store)
+	m.Created(1)
+
+	m.Apply(context.Background(), Event{OrderID: 1, To: StatusCancelled, Source: SourceGRPC})
+	if err := m.Apply(context.Background(), Event{OrderID: 1, To: StatusQueued, Source: SourceMatcher}); err == nil {
+		t.Error("expected a cancelled order not to be queued again")
+	}
+	// the matcher reporting the same cancel is fine
+	if err := m.Apply(context.Background(), Event{OrderID: 1, To: StatusCancelled, Source: SourceMatcher}); err != nil {
+		t.Errorf("expected applying the current status to be a no-op, got %v", err)
+	}
+}
+
+func TestManagerLoadsUnknownOrdersFromStore(t *testing.T) {
+	store := newFakeStatusStore()
+	store.rows[7] = db.OrderStatusMatched // from before a restart
+	m := NewManager(store)
+
+	if err := m.Apply(context.Background(), Event{OrderID: 7, To: StatusPickedUp, Source: SourceRobot}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Apply(context.Background(), Event{OrderID: 8, To: StatusQueued, Source: SourceMatcher}); err == nil {
+		t.Error("expected an error for an order the store doesn't have")
+	}
+}
+
+func TestManagerSlowWriteOnlyHoldsUpItsOrder(t *testing.T) {
+	store := newFakeStatusStore()
+	stuck := make(chan struct{})
+	store.stuck = map[int64]chan struct{}{1: stuck}
+	m := NewManager(store)
+	m.Created(1)
+	m.Created(2)
+
+	applied := make(chan error)
+	go func() {
+		applied <- m.Apply(context.Background(), Event{OrderID: 1, To: StatusQueued, Source: SourceMatcher})
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- m.Apply(context.Background(), Event{OrderID: 2, To: StatusQueued, Source: SourceMatcher})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected order 2 not to wait on order 1's write")
+	}
+
+	close(stuck)
+	if err := <-applied; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestManagerForgetsFinishedOrders(t *testing.T) {
+	store := newFakeStatusStore()
+	m := NewManager(store)
+	m.Created(1)
+	m.Created(2)
+
+	m.Apply(context.Background(), Event{OrderID: 1, To: StatusCancelled, Source: SourceGRPC})
+	m.Deleted(2)
+	if len(m.orders) != 0 || len(m.locks) != 0 {
+		t.Errorf("expected nothing kept for finished or deleted orders, got %v %v", m.orders, m.locks)
+	}
+
+	// the database still knows it
+	if s, err := m.Status(context.Background(), 1); err != nil || s != StatusCancelled {
+		t.Errorf("expected cancelled from the store, got %s %v", s, err)
+	}
+	if len(m.orders) != 0 {
+		t.Errorf("expected a terminal order read from the store not to be kept, got %v", m.orders)
+	}
+}
@@ -1,3 +1,94 @@
 package state
 
-// Robot state, jobs, position definitions
+// order lifecycle, every status an order can be in and how it gets from one to the next
+import (
+	"fmt"
+
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+)
+
+// same values as the status column in the orders table
+type Status string
+
+const (
+	StatusCreated   Status = db.OrderStatusPending
+	StatusQueued    Status = db.OrderStatusQueued
+	StatusMatched   Status = db.OrderStatusMatched
+	StatusCancelled Status = db.OrderStatusCancelled
+	StatusPickedUp  Status = db.OrderStatusPickedUp
+	StatusInTransit Status = db.OrderStatusInTransit
+	StatusArrived   Status = db.OrderStatusArrived
+	StatusDelivered Status = db.OrderStatusDelivered
+	StatusFailed    Status = db.OrderStatusFailed
+)
+
+var statusNames = map[Status]string{
+	StatusCreated:   "created",
+	StatusQueued:    "queued",
+	StatusMatched:   "matched",
+	StatusCancelled: "cancelled",
+	StatusPickedUp:  "picked_up",
+	StatusInTransit: "in_transit",
+	StatusArrived:   "arrived",
+	StatusDelivered: "delivered",
+	StatusFailed:    "failed",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status(%q)", string(s))
+}
+
+func ParseStatus(name string) (Status, error) {
+	for s, n := range statusNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown order status %q", name)
+}
+
+// the robot has it on board, it can't be handed to another robot anymore
+func (s Status) PickedUp() bool {
+	return s == StatusPickedUp || s == StatusInTransit || s == StatusArrived
+}
+
+// nothing happens to the order after these
+func (s Status) Terminal() bool {
+	return s == StatusDelivered || s == StatusCancelled || s == StatusFailed
+}
+
+var transitions = map[Status][]Status{
+	StatusCreated:   {StatusQueued, StatusCancelled, StatusFailed},
+	StatusQueued:    {StatusMatched, StatusCancelled, StatusFailed},
+	StatusMatched:   {StatusPickedUp, StatusQueued, StatusCancelled, StatusFailed}, // back to queued when the robot gives it up
+	StatusPickedUp:  {StatusInTransit, StatusCancelled, StatusFailed},
+	StatusInTransit: {StatusArrived, StatusCancelled, StatusFailed},
+	StatusArrived:   {StatusDelivered, StatusFailed},
+}
+
+func CanTransition(from Status, to Status) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// who is asking for the change, kept for the logs
+type Source string
+
+const (
+	SourceGRPC    Source = "grpc"
+	SourceMatcher Source = "matcher"
+	SourceRobot   Source = "robot"
+)
+
+type Event struct {
+	OrderID int64
+	To      Status
+	Source  Source
+}
@@ -0,0 +1,215 @@
+package state
+
+// live order tracking, statuses come from Apply and robots and positions from the websocket hub
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// how many updates a slow watcher can fall behind before the oldest get dropped
+const watchBuffer = 32
+
+// everything we know about an order at one point, each update is complete so a dropped one loses nothing
+type Update struct {
+	OrderID     int64
+	Status      Status
+	RobotID     string
+	Position    geo.Point
+	HasPosition bool
+	At          time.Time
+}
+
+type watchers struct {
+	mu          sync.Mutex
+	subs        map[int64][]chan Update
+	latest      map[int64]Update   // only kept for orders someone is watching or a robot has
+	robotOrders map[string][]int64 // orders each robot is carrying
+}
+
+func newWatchers() *watchers {
+	return &watchers{
+		subs:        make(map[int64][]chan Update),
+		latest:      make(map[int64]Update),
+		robotOrders: make(map[string][]int64),
+	}
+}
+
+// streams every change to the order until it reaches a terminal status, then the channel is closed
+// the current status is sent right away, stop lets go of the watch early
+func (m *Manager) Watch(ctx context.Context, orderID int64) (<-chan Update, func(), error) {
+	// holding the order's lock means no status change can slip in between reading the status and subscribing
+	unlock := m.lock(orderID)
+	defer unlock()
+
+	current, err := m.statusLocked(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := m.watch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan Update, watchBuffer)
+	u, ok := w.latest[orderID]
+	if !ok {
+		u = Update{OrderID: orderID, At: time.Now()}
+	}
+	u.Status = current
+	if current.Terminal() {
+		ch <- u
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	w.latest[orderID] = u
+	w.subs[orderID] = append(w.subs[orderID], ch)
+	ch <- u
+
+	stop := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		subs := w.subs[orderID]
+		for i, sub := range subs {
+			if sub == ch {
+				w.subs[orderID] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		w.forgetIfUnused(orderID)
+	}
+	return ch, stop, nil
+}
+
+// the hub calls this when it hands a robot its route
+func (m *Manager) RobotAssigned(robotID string, orderIDs []int64) {
+	w := m.watch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, orderID := range orderIDs {
+		if !slices.Contains(w.robotOrders[robotID], orderID) {
+			w.robotOrders[robotID] = append(w.robotOrders[robotID], orderID)
+		}
+		w.publish(orderID, func(u *Update) { u.RobotID = robotID })
+	}
+}
+
+// the hub calls this when orders leave a robot without being finished, a rejected or unacked assignment or a recall
+// watchers stop hearing about the robot until the order is handed to another one
+func (m *Manager) RobotReleased(robotID string, orderIDs []int64) {
+	w := m.watch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, orderID := range orderIDs {
+		if !w.dropLocked(robotID, orderID) {
+			continue
+		}
+		if u, ok := w.latest[orderID]; ok && u.RobotID == robotID { // it may already be on its way with another robot
+			w.publish(orderID, func(u *Update) {
+				u.RobotID = ""
+				u.Position = geo.Point{}
+				u.HasPosition = false
+			})
+		}
+		w.forgetIfUnused(orderID)
+	}
+}
+
+// the hub calls this on every position a robot reports
+func (m *Manager) RobotMoved(robotID string, loc geo.Point) {
+	w := m.watch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, orderID := range w.robotOrders[robotID] {
+		w.publish(orderID, func(u *Update) {
+			u.Position = loc
+			u.HasPosition = true
+		})
+	}
+}
+
+func (w *watchers) statusChanged(orderID int64, to Status) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.publish(orderID, func(u *Update) { u.Status = to })
+	if !to.Terminal() {
+		return
+	}
+
+	// nothing more will happen to it
+	for _, ch := range w.subs[orderID] {
+		close(ch)
+	}
+	delete(w.subs, orderID)
+	delete(w.latest, orderID)
+	for robotID := range w.robotOrders {
+		w.dropLocked(robotID, orderID)
+	}
+}
+
+// takes the order off the robot, reports whether the robot had it, call with mu held
+func (w *watchers) dropLocked(robotID string, orderID int64) bool {
+	orders := w.robotOrders[robotID]
+	i := slices.Index(orders, orderID)
+	if i < 0 {
+		return false
+	}
+	orders = slices.Delete(orders, i, i+1)
+	if len(orders) == 0 {
+		delete(w.robotOrders, robotID)
+	} else {
+		w.robotOrders[robotID] = orders
+	}
+	return true
+}
+
+// applies the change to the latest update and hands it to every watcher, call with mu held
+func (w *watchers) publish(orderID int64, change func(u *Update)) {
+	u, ok := w.latest[orderID]
+	if !ok {
+		if len(w.subs[orderID]) == 0 && !w.carried(orderID) {
+			return // nobody cares yet, Watch starts from the current status
+		}
+		u = Update{OrderID: orderID}
+	}
+	change(&u)
+	u.At = time.Now()
+	w.latest[orderID] = u
+
+	for _, ch := range w.subs[orderID] {
+		select {
+		case ch <- u:
+		default: // watcher fell behind, the newest update has everything the dropped one had
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- u
+		}
+	}
+}
+
+func (w *watchers) carried(orderID int64) bool {
+	for _, orders := range w.robotOrders {
+		if slices.Contains(orders, orderID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watchers) forgetIfUnused(orderID int64) {
+	if len(w.subs[orderID]) == 0 && !w.carried(orderID) {
+		delete(w.subs, orderID)
+		delete(w.latest, orderID)
+	}
+}
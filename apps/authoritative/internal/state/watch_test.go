@@ -0,0 +1,145 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func nextUpdate(t *testing.T, updates <-chan Update) Update {
+	t.Helper()
+	select {
+	case u, ok := <-updates:
+		if !ok {
+			t.Fatal("watch closed early")
+		}
+		return u
+	case <-time.After(time.Second):
+		t.Fatal("expected an update")
+	}
+	return Update{}
+}
+
+func TestWatchStreamsStatusRobotAndPosition(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStatusStore())
+	m.Created(1)
+
+	updates, stop, err := m.Watch(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stop()
+
+	if u := nextUpdate(t, updates); u.Status != StatusCreated {
+		t.Errorf("expected the current status first, got %s", u.Status)
+	}
+
+	m.Apply(ctx, Event{OrderID: 1, To: StatusQueued, Source: SourceMatcher})
+	if u := nextUpdate(t, updates); u.Status != StatusQueued {
+		t.Errorf("expected queued, got %s", u.Status)
+	}
+
+	m.RobotAssigned("robot-1", []int64{1})
+	if u := nextUpdate(t, updates); u.RobotID != "robot-1" || u.Status != StatusQueued {
+		t.Errorf("expected robot-1 with the status kept, got %+v", u)
+	}
+
+	m.RobotMoved("robot-1", geo.Point{X: 3, Y: 4})
+	u := nextUpdate(t, updates)
+	if !u.HasPosition || u.Position != (geo.Point{X: 3, Y: 4}) {
+		t.Errorf("expected the robot position, got %+v", u)
+	}
+
+	// someone else's robot moving means nothing to this order
+	m.RobotMoved("robot-2", geo.Point{X: 9, Y: 9})
+	select {
+	case u := <-updates:
+		t.Errorf("unexpected update %+v", u)
+	default:
+	}
+}
+
+func TestWatchEndsOnTerminalStatus(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStatusStore())
+	m.Created(1)
+
+	updates, stop, _ := m.Watch(ctx, 1)
+	defer stop()
+	nextUpdate(t, updates)
+
+	m.Apply(ctx, Event{OrderID: 1, To: StatusCancelled, Source: SourceGRPC})
+	if u := nextUpdate(t, updates); u.Status != StatusCancelled {
+		t.Errorf("expected the final status before the stream ends, got %s", u.Status)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("expected the watch to be closed")
+	}
+
+	// watching an order that's already done just gives its final status
+	late, _, _ := m.Watch(ctx, 1)
+	if u := nextUpdate(t, late); u.Status != StatusCancelled {
+		t.Errorf("expected cancelled, got %s", u.Status)
+	}
+	if _, ok := <-late; ok {
+		t.Error("expected the late watch to be closed")
+	}
+}
+
+func TestWatchSlowWatcherKeepsNewest(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStatusStore())
+	m.Created(1)
+	m.RobotAssigned("robot-1", []int64{1})
+
+	updates, stop, _ := m.Watch(ctx, 1)
+	defer stop()
+
+	for i := range watchBuffer * 2 {
+		m.RobotMoved("robot-1", geo.Point{X: i, Y: 0})
+	}
+
+	var last Update
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	if last.Position.X != watchBuffer*2-1 {
+		t.Errorf("expected the newest position to survive, got %+v", last.Position)
+	}
+}
+
+func TestWatchForgetsReleasedRobot(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStatusStore())
+	m.Created(1)
+
+	// handed to robot-1, which turned it down
+	m.RobotAssigned("robot-1", []int64{1})
+	m.RobotMoved("robot-1", geo.Point{X: 2, Y: 2})
+	m.RobotReleased("robot-1", []int64{1})
+
+	updates, stop, _ := m.Watch(ctx, 1)
+	defer stop()
+	if u := nextUpdate(t, updates); u.RobotID != "" || u.HasPosition {
+		t.Errorf("expected no robot on a released order, got %+v", u)
+	}
+
+	m.RobotMoved("robot-1", geo.Point{X: 3, Y: 3})
+	select {
+	case u := <-updates:
+		t.Errorf("expected robot-1 moving to mean nothing to the order anymore, got %+v", u)
+	default:
+	}
+
+	// released after it already went to robot-2, robot-2 keeps it
+	m.RobotAssigned("robot-2", []int64{1})
+	nextUpdate(t, updates)
+	m.RobotReleased("robot-1", []int64{1})
+	m.RobotMoved("robot-2", geo.Point{X: 4, Y: 4})
+	if u := nextUpdate(t, updates); u.RobotID != "robot-2" || u.Position != (geo.Point{X: 4, Y: 4}) {
+		t.Errorf("expected robot-2 to still have the order, got %+v", u)
+	}
+}
@@ -0,0 +1,127 @@
+package telemetry
+
+// positions are written to the robots table every so often instead of on every frame
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+const DefaultFlushInterval = 10 * time.Second
+
+// how stale places may get before a robot that isn't at one of them has it loaded again
+// vendors and drop-offs are added while we run, a robot at a new one shouldn't get a waypoint forever
+const placesRefresh = time.Minute
+
+// the part of db.Store flushing needs, robots point at a coordinate row rather than holding x and y
+type LocationStore interface {
+	ListCoordinates(ctx context.Context) ([]db.Coordinate, error)
+	UpsertCoordinate(ctx context.Context, c db.Coordinate) error
+	UpdateRobotLocation(ctx context.Context, id string, coordinateID string) error
+}
+
+// flushes every interval until ctx is cancelled, then once more so the last positions make it in
+func (s *Store) Run(ctx context.Context, interval time.Duration, dst LocationStore) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.Flush(flushCtx, dst); err != nil {
+				log.Printf("failed flushing robot positions on shutdown: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx, dst); err != nil {
+				log.Printf("failed flushing robot positions: %v", err)
+			}
+		}
+	}
+}
+
+// writes the position of every robot that moved since the last flush, a robot that fails is tried again next time
+func (s *Store) Flush(ctx context.Context, dst LocationStore) error {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+	s.prune()
+
+	s.mu.RLock()
+	moved := make(map[string]geo.Point)
+	for robotID, e := range s.robots {
+		if e.dirty {
+			moved[robotID] = e.reading.Loc
+		}
+	}
+	s.mu.RUnlock()
+	if len(moved) == 0 {
+		return nil
+	}
+
+	var failed error
+	for robotID, loc := range moved {
+		coordID, err := s.coordinate(ctx, dst, robotID, loc)
+		if err == nil {
+			err = dst.UpdateRobotLocation(ctx, robotID, coordID)
+		}
+		if err != nil {
+			failed = err
+			continue
+		}
+
+		s.mu.Lock()
+		if e, ok := s.robots[robotID]; ok && e.reading.Loc == loc { // it may have moved again in the meantime
+			e.dirty = false
+		}
+		s.mu.Unlock()
+	}
+	return failed
+}
+
+// finds the coordinate row for where the robot is, a vendor or drop-off if it is at one
+// anywhere else it is the robot's own waypoint, one row per robot that is moved along with it
+func (s *Store) coordinate(ctx context.Context, dst LocationStore, robotID string, loc geo.Point) (string, error) {
+	id, ok := s.places[loc]
+	if !ok && (s.places == nil || s.now().Sub(s.placesAt) >= placesRefresh) {
+		if err := s.loadPlaces(ctx, dst); err != nil {
+			return "", err
+		}
+		id, ok = s.places[loc]
+	}
+	if ok {
+		return id, nil
+	}
+
+	c := db.Coordinate{ID: waypointID(robotID), X: loc.X, Y: loc.Y, Type: db.CoordinateTypeWaypoint}
+	if err := dst.UpsertCoordinate(ctx, c); err != nil {
+		return "", fmt.Errorf("failed moving the waypoint of robot %s to %v: %w", robotID, loc, err)
+	}
+	return c.ID, nil
+}
+
+func (s *Store) loadPlaces(ctx context.Context, dst LocationStore) error {
+	coords, err := dst.ListCoordinates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed loading coordinates: %w", err)
+	}
+	s.places = make(map[geo.Point]string, len(coords))
+	for _, c := range coords {
+		if _, ok := s.places[c.Point()]; !ok && c.Type != db.CoordinateTypeWaypoint {
+			s.places[c.Point()] = c.ID
+		}
+	}
+	s.placesAt = s.now()
+	return nil
+}
+
+// the same id every time for a robot, coordinate ids are uuids
+func waypointID(robotID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte("robot-waypoint:"+robotID)).String()
+}
@@ -0,0 +1,138 @@
+package telemetry
+
+// where every robot last said it was and how it was doing, straight from its telemetry frames
+// a reading older than its ttl isn't handed out anymore, the robot has likely moved on or gone away
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+const DefaultTTL = 30 * time.Second
+
+type Reading struct {
+	RobotID string
+	Loc     geo.Point
+	Battery int      // percent, 0 if the robot didn't say
+	Speed   float64  // grid cells per second, 0 if the robot didn't say
+	Faults  []string // sensor faults the robot reported, empty when it is fine
+	At      time.Time
+}
+
+type entry struct {
+	reading Reading
+	expires time.Time
+	dirty   bool // moved since the last flush
+}
+
+type Store struct {
+	mu       sync.RWMutex
+	robots   map[string]*entry
+	ttl      time.Duration
+	now      func() time.Time
+	flushing sync.Mutex           // one flush at a time, also guards places and placesAt
+	places   map[geo.Point]string // coordinate id of every vendor and drop-off, loaded again on a miss once stale
+	placesAt time.Time            // when places was last loaded
+}
+
+type Option func(*Store)
+
+// how long a reading is good for
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+func NewStore(opts ...Option) *Store {
+	s := &Store{
+		robots: make(map[string]*entry),
+		ttl:    DefaultTTL,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// keeps the reading as the robot's latest, it is good for the store's ttl from now
+func (s *Store) Record(r Reading) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if r.At.IsZero() {
+		r.At = now
+	}
+	r.Faults = slices.Clone(r.Faults)
+
+	e, ok := s.robots[r.RobotID]
+	if !ok {
+		e = &entry{dirty: true}
+		s.robots[r.RobotID] = e
+	} else if e.reading.Loc != r.Loc {
+		e.dirty = true
+	}
+	e.reading = r
+	e.expires = now.Add(s.ttl)
+}
+
+// the robot's latest reading, false if there is none or it expired
+func (s *Store) Get(robotID string) (Reading, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.robots[robotID]
+	if !ok || !s.now().Before(e.expires) {
+		return Reading{}, false
+	}
+	r := e.reading
+	r.Faults = slices.Clone(r.Faults)
+	return r, true
+}
+
+func (s *Store) Position(robotID string) (geo.Point, bool) {
+	r, ok := s.Get(robotID)
+	return r.Loc, ok
+}
+
+// 0 and true means the robot is fresh but didn't report its speed
+func (s *Store) Speed(robotID string) (float64, bool) {
+	r, ok := s.Get(robotID)
+	return r.Speed, ok
+}
+
+// every reading that hasn't expired, sorted by robot id
+func (s *Store) Readings() []Reading {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	readings := make([]Reading, 0, len(s.robots))
+	for _, e := range s.robots {
+		if now.Before(e.expires) {
+			r := e.reading
+			r.Faults = slices.Clone(r.Faults)
+			readings = append(readings, r)
+		}
+	}
+	slices.SortFunc(readings, func(a, b Reading) int { return strings.Compare(a.RobotID, b.RobotID) })
+	return readings
+}
+
+// drops every reading that expired, positions that never got flushed go with them
+func (s *Store) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for robotID, e := range s.robots {
+		if !now.Before(e.expires) {
+			delete(s.robots, robotID)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+func newTestStore() (*Store, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(WithTTL(time.Minute))
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestReadingsExpire(t *testing.T) {
+	s, now := newTestStore()
+	s.Record(Reading{RobotID: "robot-1", Loc: geo.Point{X: 3, Y: 4}, Battery: 80, Speed: 1.5, Faults: []string{"lidar"}})
+
+	r, ok := s.Get("robot-1")
+	if !ok || r.Loc != (geo.Point{X: 3, Y: 4}) || r.Battery != 80 || r.Speed != 1.5 || len(r.Faults) != 1 {
+		t.Fatalf("expected the reading back, got %+v %v", r, ok)
+	}
+	if !r.At.Equal(*now) {
+		t.Errorf("expected the reading to be stamped with the time it came in, got %s", r.At)
+	}
+
+	*now = now.Add(59 * time.Second)
+	if _, ok := s.Position("robot-1"); !ok {
+		t.Error("expected the position to still be good")
+	}
+
+	*now = now.Add(time.Second)
+	if _, ok := s.Position("robot-1"); ok {
+		t.Error("expected the position to have expired")
+	}
+	if got := s.Readings(); len(got) != 0 {
+		t.Errorf("expected no readings, got %+v", got)
+	}
+	if _, ok := s.Get("robot-2"); ok {
+		t.Error("expected nothing for a robot that never reported")
+	}
+}
+
+func TestFlushWritesMovedRobots(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	store.InsertRobot(ctx, db.Robot{ID: "robot-1"})
+	store.InsertRobot(ctx, db.Robot{ID: "robot-2"})
+	store.InsertCoordinate(ctx, db.Coordinate{ID: "vendor-1", X: 1, Y: 1, Type: db.CoordinateTypeVendor})
+
+	s, now := newTestStore()
+	s.Record(Reading{RobotID: "robot-1", Loc: geo.Point{X: 1, Y: 1}})
+	s.Record(Reading{RobotID: "robot-2", Loc: geo.Point{X: 7, Y: 2}})
+	if err := s.Flush(ctx, store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r1, _ := store.GetRobot(ctx, "robot-1")
+	if r1.CurrentLoc != "vendor-1" {
+		t.Errorf("expected robot-1 at the vendor's coordinate, got %q", r1.CurrentLoc)
+	}
+	r2, _ := store.GetRobot(ctx, "robot-2")
+	waypoint, err := store.GetCoordinate(ctx, r2.CurrentLoc)
+	if err != nil {
+		t.Fatalf("expected robot-2 to point at a new coordinate: %v", err)
+	}
+	if waypoint.Point() != (geo.Point{X: 7, Y: 2}) || waypoint.Type != db.CoordinateTypeWaypoint {
+		t.Errorf("expected a waypoint at (7, 2), got %+v", waypoint)
+	}
+
+	// nothing moved, nothing written
+	store.UpdateRobotLocation(ctx, "robot-1", "somewhere-else")
+	s.Record(Reading{RobotID: "robot-1", Loc: geo.Point{X: 1, Y: 1}, Battery: 50})
+	if err := s.Flush(ctx, store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r1, _ := store.GetRobot(ctx, "robot-1"); r1.CurrentLoc != "somewhere-else" {
+		t.Errorf("expected a robot that didn't move to be left alone, got %q", r1.CurrentLoc)
+	}
+
+	// robot-2 keeps moving, its one waypoint moves with it
+	s.Record(Reading{RobotID: "robot-2", Loc: geo.Point{X: 8, Y: 2}})
+	s.Flush(ctx, store)
+	s.Record(Reading{RobotID: "robot-2", Loc: geo.Point{X: 8, Y: 3}})
+	s.Flush(ctx, store)
+	if r2, _ := store.GetRobot(ctx, "robot-2"); r2.CurrentLoc != waypoint.ID {
+		t.Errorf("expected the waypoint to be reused, got %q", r2.CurrentLoc)
+	}
+	if moved, _ := store.GetCoordinate(ctx, waypoint.ID); moved.Point() != (geo.Point{X: 8, Y: 3}) {
+		t.Errorf("expected the waypoint to be at (8, 3), got %+v", moved)
+	}
+	if coords, _ := store.ListCoordinates(ctx); len(coords) != 2 {
+		t.Errorf("expected the vendor and one waypoint, got %+v", coords)
+	}
+
+	// a robot that expired before it was flushed isn't written
+	s.Record(Reading{RobotID: "robot-2", Loc: geo.Point{X: 9, Y: 9}})
+	*now = now.Add(time.Minute)
+	s.Flush(ctx, store)
+	if r2, _ := store.GetRobot(ctx, "robot-2"); r2.CurrentLoc != waypoint.ID {
+		t.Errorf("expected an expired position not to be written, got %q", r2.CurrentLoc)
+	}
+}
+
+func TestFlushFindsPlacesAddedLater(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	store.InsertRobot(ctx, db.Robot{ID: "robot-1"})
+
+	s, now := newTestStore()
+	s.Record(Reading{RobotID: "robot-1", Loc: geo.Point{X: 5, Y: 5}})
+	if err := s.Flush(ctx, store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a drop-off added after the places were loaded, the robot gets there once they are loaded again
+	store.InsertCoordinate(ctx, db.Coordinate{ID: "dropoff-1", X: 6, Y: 6, Type: db.CoordinateTypeDropoff})
+	*now = now.Add(placesRefresh)
+	s.Record(Reading{RobotID: "robot-1", Loc: geo.Point{X: 6, Y: 6}})
+	if err := s.Flush(ctx, store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, _ := store.GetRobot(ctx, "robot-1"); r.CurrentLoc != "dropoff-1" {
+		t.Errorf("expected robot-1 at the new drop-off, got %q", r.CurrentLoc)
+	}
+}
@@ -0,0 +1,138 @@
+package wsockets
+
+// robots have to accept or reject every assignment before a deadline, a reject or silence puts the orders back in the matcher
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+const (
+	DefaultAckTimeout        = 10 * time.Second
+	DefaultUnhealthyCooldown = time.Minute
+)
+
+type pendingAssignment struct {
+	robotID  string
+	orderIDs []int
+	frame    []byte // sent again as is if the robot resumes its session before acking
+	timer    *time.Timer
+}
+
+// sends the route and starts the clock on the robot's ack
+func (h *Hub) assign(c *Client, robotID string, orderIDs []int, assignment *Assignment) {
+	p := &pendingAssignment{robotID: robotID, orderIDs: orderIDs}
+	frame, frameID, err := encodeFrame(TypeAssignment, assignment)
+	if err != nil {
+		log.Println(err.Error())
+		go h.assignmentFailed(p, robots.AttemptUndelivered, "assignment couldn't be encoded")
+		return
+	}
+	p.frame = frame
+
+	// pending before it is pushed so a quick ack can't get here first, the push may wait on room so mu isn't held across it
+	h.mu.Lock()
+	h.pending[frameID] = p
+	p.timer = time.AfterFunc(h.ackTimeout, func() {
+		if p := h.takePending(frameID, robotID); p != nil {
+			h.assignmentFailed(p, robots.AttemptTimedOut, fmt.Sprintf("robot didn't ack the assignment within %s", h.ackTimeout))
+		}
+	})
+	h.mu.Unlock()
+
+	if !c.push(ClassAssignment, frame) {
+		if p := h.takePending(frameID, robotID); p != nil {
+			go h.assignmentFailed(p, robots.AttemptUndelivered, "assignment couldn't be sent to the robot")
+		}
+	}
+}
+
+// hands back the assignment if it is still waiting on this robot, whoever takes it decides what happens to it
+func (h *Hub) takePending(frameID string, robotID string) *pendingAssignment {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.pending[frameID]
+	if !ok || p.robotID != robotID {
+		return nil
+	}
+	delete(h.pending, frameID)
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	return p
+}
+
+// whether an assignment sent to the robot is still waiting on its ack
+func (h *Hub) awaitingAck(robotID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, p := range h.pending {
+		if p.robotID == robotID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) ack(c *Client, env *Envelope, ack *Ack) {
+	p := h.takePending(ack.Ref, *c.RobotID)
+	if p == nil { // already timed out, or not an assignment this robot was sent
+		c.sendError(ErrCodeRejected, env.ID, fmt.Sprintf("no assignment %q is waiting on an ack", ack.Ref))
+		return
+	}
+
+	if ack.Accepted {
+		h.fleet.RecordAttempt(p.robotID, p.orderIDs, robots.AttemptAccepted, "")
+		return
+	}
+
+	reason := "robot rejected the assignment"
+	if ack.Reason != "" {
+		reason += ": " + ack.Reason
+	}
+	h.assignmentFailed(p, robots.AttemptRejected, reason)
+}
+
+// benches the robot and puts its orders back in the queue, they keep the priority and wait they had
+// blocks on the matcher, never call it from Run
+func (h *Hub) assignmentFailed(p *pendingAssignment, outcome robots.AttemptOutcome, reason string) {
+	log.Printf("assignment of orders %v to robot %s %s: %s", p.orderIDs, p.robotID, outcome, reason)
+
+	h.fleet.RecordAttempt(p.robotID, p.orderIDs, outcome, reason)
+	h.fleet.MarkUnhealthy(p.robotID, time.Now().Add(h.unhealthyCooldown))
+	h.states.RobotReleased(p.robotID, watchedOrders(p.orderIDs))
+
+	// one order the matcher won't take back doesn't strand the rest
+	for _, orderID := range p.orderIDs {
+		if _, err := h.orm.UnassignOrder(orderID, reason); err != nil {
+			log.Printf("failed requeueing order %d: %v", orderID, err)
+		}
+	}
+
+	// the matcher only looks at a robot again when it reports in, do that for it once the cooldown is over
+	time.AfterFunc(h.unhealthyCooldown, func() { h.resubmit(p.robotID) })
+}
+
+// tells the matcher about the robot again so it re-checks whether it can take routes
+func (h *Hub) resubmit(robotID string) {
+	h.mu.RLock()
+	c := h.clients[h.rClients[robotID]]
+	h.mu.RUnlock()
+	if c == nil { // gone, it'll report in when it reconnects
+		return
+	}
+
+	c.mu.Lock()
+	t := c.last
+	c.mu.Unlock()
+
+	update := matcher.NewRobotUpdate(h.fleet.State(robotID), robotID, geo.Point{X: t.X, Y: t.Y}).
+		WithCapacity(t.Compartments, t.FreeCapacity)
+	h.submitRobot(update)
+}
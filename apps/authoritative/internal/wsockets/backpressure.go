@@ -0,0 +1,212 @@
+package wsockets
+
+// what happens when a robot reads slower than the hub writes to it
+// every client has one queue in front of its write pump, frames keep their order but each class of message gets its own room in it
+// and its own policy for when that room is full
+import (
+	"log"
+	"time"
+)
+
+type MessageClass int
+
+const (
+	ClassControl    MessageClass = iota // welcomes and errors
+	ClassAssignment                     // routes and cancels, losing one strands orders
+	ClassTelemetry                      // broadcasts, a newer one makes the older ones useless
+
+	classCount
+)
+
+func (c MessageClass) String() string {
+	switch c {
+	case ClassControl:
+		return "control"
+	case ClassAssignment:
+		return "assignment"
+	case ClassTelemetry:
+		return "telemetry"
+	}
+	return "unknown"
+}
+
+// class of the frames the hub sends, anything not listed is telemetry
+var messageClasses = map[string]MessageClass{
+	TypeWelcome:    ClassControl,
+	TypeError:      ClassControl,
+	TypeAssignment: ClassAssignment,
+	TypeCancel:     ClassAssignment,
+}
+
+func classOf(msgType string) MessageClass {
+	if class, ok := messageClasses[msgType]; ok {
+		return class
+	}
+	return ClassTelemetry
+}
+
+type BackpressureMode int
+
+const (
+	ModeDropOldest       BackpressureMode = iota // the oldest queued frame of the class makes room
+	ModeBlockWithTimeout                         // the sender waits for room, the frame isn't sent if none frees up in time
+	ModeDisconnectAfter                          // the frame isn't sent, after enough misses in a row the robot is hung up on
+)
+
+type Backpressure struct {
+	Mode        BackpressureMode
+	Buffer      int           // frames of the class a client can have queued
+	Timeout     time.Duration // ModeBlockWithTimeout
+	MaxFailures int           // ModeDisconnectAfter
+}
+
+func DropOldest(buffer int) Backpressure {
+	return Backpressure{Mode: ModeDropOldest, Buffer: buffer}
+}
+
+func BlockWithTimeout(buffer int, timeout time.Duration) Backpressure {
+	return Backpressure{Mode: ModeBlockWithTimeout, Buffer: buffer, Timeout: timeout}
+}
+
+func DisconnectAfter(buffer int, failures int) Backpressure {
+	return Backpressure{Mode: ModeDisconnectAfter, Buffer: buffer, MaxFailures: failures}
+}
+
+const (
+	DefaultControlBuffer    = 32
+	DefaultControlFailures  = 3
+	DefaultAssignmentBuffer = 64
+	DefaultAssignmentBlock  = time.Second
+	DefaultTelemetryBuffer  = 64
+)
+
+func defaultBackpressure() map[MessageClass]Backpressure {
+	return map[MessageClass]Backpressure{
+		ClassControl:    DisconnectAfter(DefaultControlBuffer, DefaultControlFailures),
+		ClassAssignment: BlockWithTimeout(DefaultAssignmentBuffer, DefaultAssignmentBlock),
+		ClassTelemetry:  DropOldest(DefaultTelemetryBuffer),
+	}
+}
+
+// sets what happens when a client has no room left for frames of the class
+// assignments and cancels are pushed from Run, blocking them stalls the hub for up to the timeout
+func WithBackpressure(class MessageClass, policy Backpressure) HubOption {
+	return func(h *Hub) {
+		if policy.Buffer < 1 {
+			policy.Buffer = 1
+		}
+		h.backpressure[class] = policy
+	}
+}
+
+type queuedFrame struct {
+	class MessageClass
+	data  []byte
+}
+
+// queues a frame for the write pump following the class's policy, false if it won't be sent
+func (c *Client) push(class MessageClass, frame []byte) bool {
+	policy := c.hub.backpressure[class]
+	var deadline <-chan time.Time
+
+	c.mu.Lock()
+	for c.queued[class] >= policy.Buffer && !c.closed {
+		switch policy.Mode {
+		case ModeDropOldest:
+			c.dropOldestLocked(class)
+			log.Printf("client %s is too far behind, dropped its oldest %s frame", c.ID, class)
+
+		case ModeBlockWithTimeout:
+			if deadline == nil {
+				timer := time.NewTimer(policy.Timeout)
+				defer timer.Stop()
+				deadline = timer.C
+			}
+			room := c.room
+			c.mu.Unlock()
+			select {
+			case <-room:
+			case <-c.quit:
+			case <-deadline:
+				log.Printf("client %s had no room for a %s frame within %s", c.ID, class, policy.Timeout)
+				return false
+			}
+			c.mu.Lock()
+
+		default:
+			c.failures[class]++
+			failures := c.failures[class]
+			c.mu.Unlock()
+			log.Printf("client %s is too far behind, dropped a %s frame (%d in a row)", c.ID, class, failures)
+			if failures >= policy.MaxFailures {
+				// the read pump notices the hang up and unregisters it like any other disconnect
+				log.Printf("client %s dropped %d %s frames in a row, hanging up", c.ID, failures, class)
+				c.close()
+			}
+			return false
+		}
+	}
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	c.queue = append(c.queue, queuedFrame{class: class, data: frame})
+	c.queued[class]++
+	c.failures[class] = 0
+	c.mu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default: // already nudged
+	}
+	return true
+}
+
+func (c *Client) dropOldestLocked(class MessageClass) {
+	for i, f := range c.queue {
+		if f.class == class {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			c.queued[class]--
+			return
+		}
+	}
+}
+
+// hands everything queued to the write pump and wakes up senders waiting on room
+func (c *Client) take() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	frames := make([][]byte, len(c.queue))
+	for i, f := range c.queue {
+		frames[i] = f.data
+	}
+	c.queue = nil
+	c.queued = [classCount]int{}
+	close(c.room)
+	c.room = make(chan struct{})
+	return frames
+}
+
+// sends a frame to every robot that has said hello, frames of the telemetry class make room for themselves
+func (h *Hub) Broadcast(msgType string, payload any) {
+	frame, _, err := encodeFrame(msgType, payload)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.rClients))
+	for _, clientID := range h.rClients {
+		if c := h.clients[clientID]; c != nil {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	class := classOf(msgType)
+	for _, c := range clients {
+		c.push(class, frame)
+	}
+}
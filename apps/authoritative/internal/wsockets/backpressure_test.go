@@ -0,0 +1,110 @@
+package wsockets
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// a client nobody is writing for, frames stay queued until the test takes them
+func stuckClient(opts ...HubOption) *Client {
+	return newClient(NewHub(nil, nil, nil, nil, nil, opts...), nil, "client-1", "robot-1")
+}
+
+func frames(names ...string) [][]byte {
+	out := make([][]byte, len(names))
+	for i, name := range names {
+		out[i] = []byte(name)
+	}
+	return out
+}
+
+func TestTelemetryDropsItsOldestFrame(t *testing.T) {
+	c := stuckClient(WithBackpressure(ClassTelemetry, DropOldest(2)))
+
+	c.push(ClassControl, []byte("welcome"))
+	for _, frame := range frames("t1", "t2", "t3") {
+		if !c.push(ClassTelemetry, frame) {
+			t.Errorf("expected telemetry to always be queued, %s wasn't", frame)
+		}
+	}
+
+	got := c.take()
+	if want := frames("welcome", "t2", "t3"); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestAssignmentsWaitForRoom(t *testing.T) {
+	c := stuckClient(WithBackpressure(ClassAssignment, BlockWithTimeout(1, 500*time.Millisecond)))
+	c.push(ClassAssignment, []byte("a1"))
+
+	pushed := make(chan bool)
+	go func() { pushed <- c.push(ClassAssignment, []byte("a2")) }()
+	select {
+	case <-pushed:
+		t.Fatal("expected the push to wait for room")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.take()
+	if ok := <-pushed; !ok {
+		t.Fatal("expected the push to go through once the queue was taken")
+	}
+	if got := c.take(); !slices.EqualFunc(got, frames("a2"), slices.Equal) {
+		t.Errorf("expected only a2 left, got %q", got)
+	}
+
+	// nobody takes it this time
+	c = stuckClient(WithBackpressure(ClassAssignment, BlockWithTimeout(1, 20*time.Millisecond)))
+	c.push(ClassAssignment, []byte("a1"))
+	start := time.Now()
+	if c.push(ClassAssignment, []byte("a2")) {
+		t.Error("expected the push to give up")
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("expected the push to wait out its timeout, gave up after %s", waited)
+	}
+}
+
+func TestSlowClientIsHungUpOnAfterRepeatedFailures(t *testing.T) {
+	c := stuckClient(WithBackpressure(ClassControl, DisconnectAfter(1, 2)))
+
+	c.push(ClassControl, []byte("e1"))
+	if c.push(ClassControl, []byte("e2")) {
+		t.Error("expected a full queue to turn the frame away")
+	}
+	c.take() // caught up, the misses start over
+	c.push(ClassControl, []byte("e3"))
+	if c.push(ClassControl, []byte("e4")) {
+		t.Error("expected a full queue to turn the frame away")
+	}
+	select {
+	case <-c.quit:
+		t.Fatal("expected the client to get another go after catching up")
+	default:
+	}
+
+	c.push(ClassControl, []byte("e5"))
+	select {
+	case <-c.quit:
+	default:
+		t.Fatal("expected the client to be hung up on after two misses in a row")
+	}
+	if c.push(ClassTelemetry, []byte("t1")) {
+		t.Error("expected nothing to be queued for a closed client")
+	}
+}
+
+func TestBroadcastReachesRobotsThatSaidHello(t *testing.T) {
+	h := newTestHub(t)
+	greeted := h.greet(t, "robot-1")
+	h.dial(t, "robot-2") // never says hello
+
+	h.hub.Broadcast("notice", &Error{Message: "depot closing early"})
+	var notice Error
+	expectFrame(t, greeted, "notice", &notice)
+	if notice.Message != "depot closing early" {
+		t.Errorf("expected the broadcast payload, got %+v", notice)
+	}
+}
@@ -0,0 +1,282 @@
+package wsockets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+// these are meant to be run with -race
+
+func TestBadMatchesDontStopTheHub(t *testing.T) {
+	store := db.NewMemoryStore()
+	auth, err := security.NewRobotAuth([]byte("test-secret-that-is-long-enough-for-hmac"), store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fleet := robots.NewManager()
+	orm := matcher.CreateOrderRobotMatcher(matcher.WithRobots(fleet))
+	ctx, cancel := context.WithCancel(context.Background())
+	orm.StartORM(ctx) // nothing gets matched, its own channel is never read
+
+	matches := make(chan *matcher.OrderRobotMatch)
+	hub := NewHub(orm, matches, fleet, state.NewManager(store), auth)
+	go hub.Run()
+	t.Cleanup(func() {
+		close(matches)
+		<-hub.Done()
+		cancel()
+		orm.Wait()
+	})
+
+	for _, match := range []*matcher.OrderRobotMatch{
+		{OrderID: 1, RobotID: "ghost", Route: []matcher.RouteStop{{OrderID: 1}}}, // used to return out of Run
+		{OrderID: 1, RobotID: "ghost", Recall: true},
+		nil, // panics in the handler
+		{OrderID: 2, RobotID: "ghost", Route: []matcher.RouteStop{{OrderID: 2}}},
+	} {
+		select {
+		case matches <- match:
+		case <-hub.Done():
+			t.Fatal("expected the hub to keep running")
+		case <-time.After(time.Second):
+			t.Fatal("expected the hub to keep taking matches")
+		}
+	}
+
+	deadline := time.After(time.Second)
+	for len(fleet.Attempts("ghost")) < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected both routes to be recorded as undelivered, got %+v", fleet.Attempts("ghost"))
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	for _, a := range fleet.Attempts("ghost") {
+		if a.Outcome != robots.AttemptUndelivered {
+			t.Errorf("expected the route to be undelivered, got %+v", a)
+		}
+	}
+}
+
+// orders robots have taken, an order taken twice means one was handed out while another robot still had it
+type takenOrders struct {
+	mu    sync.Mutex
+	taken map[int]string
+	dupes []string
+}
+
+func (o *takenOrders) take(robotID string, orderIDs []int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, orderID := range orderIDs {
+		if other, ok := o.taken[orderID]; ok {
+			o.dupes = append(o.dupes, fmt.Sprintf("order %d taken by %s and %s", orderID, other, robotID))
+		}
+		o.taken[orderID] = robotID
+	}
+}
+
+func (o *takenOrders) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.taken)
+}
+
+// a robot driven by the test, it turns down some routes, drops its connection mid-delivery and sends junk
+type simRobot struct {
+	h      *testHub
+	id     string
+	rng    *rand.Rand
+	orders *takenOrders
+	stop   <-chan struct{}
+
+	conn   *websocket.Conn
+	frames chan Envelope // from the current connection, closed when it is
+	token  string
+}
+
+func (r *simRobot) send(msgType string, payload any) error {
+	raw, _ := json.Marshal(payload)
+	return r.conn.WriteJSON(&Envelope{Version: ProtocolVersion, Type: msgType, Payload: raw})
+}
+
+// (re)connects, resuming the session if it has one
+func (r *simRobot) connect(orderID int) error {
+	token, _, err := r.h.auth.Issue(r.id)
+	if err != nil {
+		return err
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(r.h.url, http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		return fmt.Errorf("%s failed connecting: %w", r.id, err)
+	}
+	r.conn = conn
+	r.frames = make(chan Envelope, 16)
+	go func(conn *websocket.Conn, frames chan Envelope) { // also answers the hub's pings
+		defer close(frames)
+		for {
+			var env Envelope
+			if err := conn.ReadJSON(&env); err != nil {
+				return
+			}
+			frames <- env
+		}
+	}(conn, r.frames)
+
+	if err := r.send(TypeHello, &Hello{RobotID: r.id, ProtocolVersion: ProtocolVersion, SessionToken: r.token, OrderID: orderID}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case env, ok := <-r.frames:
+			if !ok {
+				return fmt.Errorf("%s was hung up on before its welcome", r.id)
+			}
+			if env.Type == TypeError {
+				return fmt.Errorf("%s was turned away: %s", r.id, env.Payload)
+			}
+			if env.Type != TypeWelcome {
+				continue
+			}
+			var welcome Welcome
+			json.Unmarshal(env.Payload, &welcome)
+			if r.token != "" && !welcome.Resumed {
+				return fmt.Errorf("%s lost its session", r.id)
+			}
+			r.token = welcome.SessionToken
+			return nil
+		case <-time.After(2 * time.Second):
+			return fmt.Errorf("%s never got a welcome", r.id)
+		}
+	}
+}
+
+func (r *simRobot) run() error {
+	defer func() { r.conn.Close() }()
+	if err := r.connect(0); err != nil {
+		return err
+	}
+
+	// junk the hub has to shrug off
+	r.conn.WriteMessage(websocket.TextMessage, []byte("{not json"))
+	r.send("teleport", &struct{}{})
+	r.send(TypeAck, &Ack{Ref: "no-such-assignment", Accepted: true})
+
+	r.send(TypeTelemetry, &Telemetry{State: "idle"})
+	heartbeat := time.NewTicker(20 * time.Millisecond)
+	defer heartbeat.Stop()
+	for {
+		var env Envelope
+		var ok bool
+		select {
+		case <-r.stop:
+			return nil
+		case <-heartbeat.C:
+			r.send(TypeHeartbeat, &Heartbeat{State: "idle", Battery: 90})
+			continue
+		case env, ok = <-r.frames:
+			if !ok {
+				return fmt.Errorf("%s lost its connection", r.id)
+			}
+		}
+		if env.Type != TypeAssignment {
+			continue
+		}
+
+		var assignment Assignment
+		json.Unmarshal(env.Payload, &assignment)
+		if r.rng.Intn(5) == 0 {
+			r.send(TypeAck, &Ack{Ref: env.ID, Accepted: false, Reason: "not feeling it"})
+			continue
+		}
+
+		orderIDs := make([]int, 0, len(assignment.Stops))
+		for _, stop := range assignment.Stops {
+			orderIDs = append(orderIDs, stop.OrderID)
+		}
+		r.orders.take(r.id, orderIDs)
+		r.send(TypeAck, &Ack{Ref: env.ID, Accepted: true})
+		r.send(TypeTelemetry, &Telemetry{State: "to_pickup"})
+
+		if r.rng.Intn(3) == 0 { // drops off the network on the way and comes back
+			r.conn.Close()
+			if err := r.connect(assignment.OrderID); err != nil {
+				return err
+			}
+		}
+
+		for _, s := range []string{"loading", "to_dropoff", "delivering", "idle"} {
+			r.send(TypeTelemetry, &Telemetry{State: s})
+		}
+	}
+}
+
+func TestHubUnderManyRobots(t *testing.T) {
+	const robotCount = 20
+	const orderCount = 100
+
+	h := newTestHub(t, WithUnhealthyCooldown(20*time.Millisecond))
+	orders := &takenOrders{taken: make(map[int]string)}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, robotCount)
+	for i := 0; i < robotCount; i++ {
+		id := fmt.Sprintf("sim-%d", i)
+		h.store.InsertRobot(context.Background(), db.Robot{ID: id, Status: db.RobotStatusActive})
+		r := &simRobot{h: h, id: id, rng: rand.New(rand.NewSource(int64(i))), orders: orders, stop: stop}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.run(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	for i := 1; i <= orderCount; i++ {
+		loc := geo.Point{X: i % 7, Y: i % 5}
+		h.orm.SubmitOrder(matcher.CreateOrder("user", i, 0, loc, loc, matcher.Priority(i%4)))
+	}
+
+	deadline := time.After(20 * time.Second)
+	for orders.count() < orderCount {
+		select {
+		case err := <-errs:
+			t.Fatalf("simulated robot failed: %v", err)
+		case <-deadline:
+			t.Fatalf("expected every order to be taken, got %d of %d", orders.count(), orderCount)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	close(errs)
+	for err := range errs {
+		t.Errorf("simulated robot failed: %v", err)
+	}
+	for _, dupe := range orders.dupes {
+		t.Error(dupe)
+	}
+	select {
+	case <-h.hub.Done():
+		t.Error("expected the hub to still be running")
+	default:
+	}
+}
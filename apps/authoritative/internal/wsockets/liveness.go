@@ -0,0 +1,77 @@
+package wsockets
+
+// two layers of liveness, websocket pings catch dead connections and heartbeats catch robots whose software stopped answering
+// a robot that misses heartbeats goes stale and gets no new routes, if it keeps missing them it is hung up on and goes offline
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+)
+
+const (
+	DefaultPongWait          = 60 * time.Second
+	DefaultWriteWait         = 10 * time.Second
+	DefaultHeartbeatInterval = 5 * time.Second
+	DefaultStaleAfter        = 2 // missed heartbeats
+	DefaultOfflineAfter      = 6
+)
+
+func (h *Hub) heartbeat(c *Client, env *Envelope, hb *Heartbeat) {
+	robotState, err := robots.ParseState(hb.State)
+	if err != nil {
+		c.sendError(ErrCodeBadPayload, env.ID, err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	c.lastHeartbeat = time.Now()
+	wasStale := c.stale
+	c.stale = false
+	c.mu.Unlock()
+
+	robotID := *c.RobotID
+	h.fleet.Heartbeat(robotID, hb.Battery)
+
+	// the state is only logged, a heartbeat sent just before a route went out would otherwise undo the assignment
+	// telemetry is what moves robots
+	if current := h.fleet.State(robotID); robotState != current {
+		log.Printf("robot %s heartbeat says %s, fleet has it %s", robotID, robotState, current)
+	}
+	if wasStale {
+		log.Printf("robot %s is sending heartbeats again", robotID)
+		h.resubmit(robotID)
+	}
+}
+
+// runs on the hub's ticker, never blocks on the matcher
+func (h *Hub) checkHeartbeats() {
+	now := time.Now()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, c := range h.clients {
+		c.mu.Lock()
+		missed := int(now.Sub(c.lastHeartbeat) / h.heartbeatInterval)
+		wentStale := missed >= h.staleAfter && !c.stale && c.RobotID != nil
+		if wentStale {
+			c.stale = true
+		}
+		robotID := c.RobotID
+		c.mu.Unlock()
+
+		if missed >= h.offlineAfter {
+			// the write pump hangs up, the read pump then errors out and unregisters the robot, which takes it offline
+			log.Printf("client %s missed %d heartbeats, hanging up", c.ID, missed)
+			c.sendError(ErrCodeRejected, "", fmt.Sprintf("missed %d heartbeats", missed))
+			c.close()
+			continue
+		}
+		if wentStale {
+			log.Printf("robot %s missed %d heartbeats, no new routes until it sends one", *robotID, missed)
+			h.fleet.SetStale(*robotID, true)
+			go h.resubmit(*robotID) // the matcher drops it from the robot queue
+		}
+	}
+}
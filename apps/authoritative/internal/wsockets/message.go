@@ -1,16 +1,75 @@
 package wsockets
 
-type Message struct {
-	Type    string `json:"type"`
-	Payload any    `json:"payload"`
+// payloads of every frame type in protocol.go
+
+type Hello struct {
+	RobotID         string   `json:"robot_id"` // has to be the robot the token was issued to
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	SessionToken    string   `json:"session_token,omitempty"` // from the last welcome, to pick up a route after a dropped connection
+	OrderID         int      `json:"order_id,omitempty"`      // the order the robot is working on, checked against its route when resuming
 }
 
-type RobotUpdate struct {
-	RobotID string `json:"robot_id"`
-	Status  string `json:"status"`
+type Welcome struct {
+	ProtocolVersion   int      `json:"protocol_version"`
+	Capabilities      []string `json:"capabilities"`          // the robot's capabilities the server also supports
+	HeartbeatInterval int64    `json:"heartbeat_interval_ms"` // how often the robot has to send a heartbeat
+	SessionToken      string   `json:"session_token"`         // hello with it after a dropped connection to keep the route
+	Resumed           bool     `json:"resumed,omitempty"`     // the route was kept, anything missed while away follows
 }
 
-type RobotMatch struct {
-	RobotID string `json:"robot_id"`
+type Telemetry struct {
+	State        string   `json:"state"` // idle, assigned, to_pickup, loading, to_dropoff, delivering, returning, faulted or offline
+	X            int      `json:"x"`     // position on the campus grid
+	Y            int      `json:"y"`
+	Compartments int      `json:"compartments,omitempty"` // left out by single compartment robots
+	FreeCapacity int      `json:"free_capacity,omitempty"`
+	Battery      int      `json:"battery,omitempty"` // percent
+	Speed        float64  `json:"speed,omitempty"`   // grid cells per second
+	Faults       []string `json:"faults,omitempty"`  // sensor faults, left out when there are none
+}
+
+// a delivery route, the robot picks up every order at the vendor then drops them off in order
+type Assignment struct {
+	OrderID int         `json:"order_id"` // first order matched, kept for robots that only read one
+	PickupX int         `json:"pickup_x"`
+	PickupY int         `json:"pickup_y"`
+	Stops   []RouteStop `json:"stops"`
+}
+
+type RouteStop struct {
+	OrderID     int `json:"order_id"`
+	Compartment int `json:"compartment"`
+	DropoffX    int `json:"dropoff_x"`
+	DropoffY    int `json:"dropoff_y"`
+}
+
+// the order was cancelled or taken off the robot, it should leave it out of the route
+type Cancel struct {
 	OrderID int    `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+// answers an assignment, Ref is the assignment frame's id
+type Ack struct {
+	Ref      string `json:"ref"`
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"` // why it was rejected
+}
+
+// the robot moving an order along: picked_up, in_transit, arrived, delivered or failed
+type Progress struct {
+	OrderID int64  `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+type Heartbeat struct {
+	State   string `json:"state"`
+	Battery int    `json:"battery"` // percent
+}
+
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Ref     string `json:"ref,omitempty"` // id of the frame that caused it, if it had one
 }
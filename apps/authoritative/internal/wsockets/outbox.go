@@ -0,0 +1,50 @@
+package wsockets
+
+// robot updates on their way to the matcher
+// the matcher can be busy sending routes to Run, so Run and the read pumps queue updates here instead of waiting on it
+import (
+	"log"
+	"sync"
+
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+)
+
+type outbox struct {
+	mu      sync.Mutex
+	updates []*matcher.RobotUpdate
+	ready   chan struct{} // nudged whenever updates is non empty
+}
+
+// never blocks, updates reach the matcher in the order they were submitted
+func (h *Hub) submitRobot(update *matcher.RobotUpdate) {
+	h.outbox.mu.Lock()
+	h.outbox.updates = append(h.outbox.updates, update)
+	h.outbox.mu.Unlock()
+
+	select {
+	case h.outbox.ready <- struct{}{}:
+	default: // already nudged
+	}
+}
+
+// started by Run, hands queued updates to the matcher until the hub stops
+func (h *Hub) forwardRobots() {
+	for {
+		select {
+		case <-h.outbox.ready:
+		case <-h.done:
+			return
+		}
+
+		h.outbox.mu.Lock()
+		updates := h.outbox.updates
+		h.outbox.updates = nil
+		h.outbox.mu.Unlock()
+
+		for _, update := range updates {
+			if err := h.orm.SubmitRobot(update); err != nil {
+				log.Printf("failed handing robot update to the matcher: %v", err)
+			}
+		}
+	}
+}
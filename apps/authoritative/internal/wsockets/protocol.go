@@ -0,0 +1,88 @@
+package wsockets
+
+// the robot protocol, every frame both ways is an Envelope whose payload is decided by its type
+// a connection starts with the robot's hello, the server answers with welcome or an error and nothing else is accepted before that
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// bumped whenever a change would break robots speaking the old one
+const ProtocolVersion = 1
+
+// robot -> server
+const (
+	TypeHello     = "hello"
+	TypeTelemetry = "telemetry" // state, position and capacity, sent whenever any of them change
+	TypeAck       = "ack"       // accepts or rejects an assignment
+	TypeProgress  = "progress"  // moves an order along the route
+	TypeHeartbeat = "heartbeat"
+)
+
+// server -> robot
+const (
+	TypeWelcome    = "welcome"
+	TypeAssignment = "assignment"
+	TypeCancel     = "cancel" // drop an order from the route
+)
+
+// both ways
+const TypeError = "error"
+
+// what goes in Error.Code
+const (
+	ErrCodeBadFrame           = "bad_frame" // not json or not an envelope
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeUnknownType        = "unknown_type"
+	ErrCodeBadPayload         = "bad_payload"
+	ErrCodeHandshakeRequired  = "handshake_required" // anything but hello before the handshake
+	ErrCodeForbidden          = "forbidden"          // the robot tried to speak for another robot
+	ErrCodeRejected           = "rejected"           // understood, but the server won't do it
+)
+
+// optional features a robot can announce in its hello, the welcome echoes back the ones the server has too
+const (
+	CapabilityBatchedRoutes = "batched_routes" // can carry several orders on one trip, robots without it get one order at a time
+)
+
+var serverCapabilities = []string{
+	CapabilityBatchedRoutes,
+}
+
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"` // set on every server frame, acks and errors point back at it
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func encodeFrame(msgType string, payload any) ([]byte, string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed marshalling %s payload: %w", msgType, err)
+	}
+
+	id := uuid.NewString()
+	frame, err := json.Marshal(&Envelope{
+		Version: ProtocolVersion,
+		Type:    msgType,
+		ID:      id,
+		Payload: raw,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed marshalling %s frame: %w", msgType, err)
+	}
+	return frame, id, nil
+}
+
+func decodePayload(env *Envelope, payload any) error {
+	if len(env.Payload) == 0 {
+		return fmt.Errorf("%s frame has no payload", env.Type)
+	}
+	if err := json.Unmarshal(env.Payload, payload); err != nil {
+		return fmt.Errorf("bad %s payload: %w", env.Type, err)
+	}
+	return nil
+}
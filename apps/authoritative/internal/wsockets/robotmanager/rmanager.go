@@ -1,26 +1,47 @@
 package robotmanager
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
 	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/wsockets"
 )
 
-func StartRobotManager(orm *matcher.OrderRobotMatcher, match chan (*matcher.OrderRobotMatch)) {
-	hub := wsockets.NewHub(orm, match)
+// serves the robot websocket until ctx is cancelled and the hub has let go of every robot
+func StartRobotManager(ctx context.Context, orm *matcher.OrderRobotMatcher, match <-chan (*matcher.OrderRobotMatch), fleet *robots.Manager, states *state.Manager, auth *security.RobotAuth, opts ...wsockets.HubOption) {
+	hub := wsockets.NewHub(orm, match, fleet, states, auth, opts...)
 	go hub.Run()
 
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		wsockets.HandleWebSocket(hub, w, r)
 	})
 
 	addr := ":8080"
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("websocket server shutdown: %v", err)
+		}
+	}()
 
 	log.Printf("websocket server starting at %s", addr)
-	err := http.ListenAndServe(addr, nil)
-	if err != nil {
+	err := srv.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		log.Fatal("Listen and Serve Failed", err)
 	}
+
+	// the hub stops once the matcher closes the matches channel
+	<-hub.Done()
+	log.Println("robot manager stopped")
 }
@@ -0,0 +1,177 @@
+package wsockets
+
+// a robot whose connection drops mid-delivery gets a while to reconnect and pick up where it left off
+// the welcome hands it a session token, a hello carrying that token (and the order it is working on) re-binds the new connection
+// to the route it already has, and everything the hub had for it in the meantime is sent again
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+const DefaultResumeWindow = 2 * time.Minute
+
+type session struct {
+	token    string
+	clientID string       // the connection it is bound to, empty while away
+	orders   map[int]bool // on the robot's route
+	queued   [][]byte     // frames for the robot while it was away, sent again in order when it is back
+	expiry   *time.Timer  // set while away
+}
+
+// starts a fresh session for the robot, reports whether an old one was dropped
+// the caller takes the robot offline in that case, whatever it was doing is lost
+func (h *Hub) newSessionLocked(robotID string, clientID string) (*session, bool) {
+	old := h.sessions[robotID]
+	dropped := old != nil
+	if old != nil {
+		h.releaseLocked(old, clientID)
+	}
+
+	s := &session{token: uuid.NewString(), clientID: clientID, orders: make(map[int]bool)}
+	h.sessions[robotID] = s
+	return s, dropped
+}
+
+// re-binds the robot's session to the new connection and hands back what has to be sent again
+func (h *Hub) resumeLocked(robotID string, clientID string, hello *Hello) (*session, [][]byte, error) {
+	s := h.sessions[robotID]
+	if s == nil || subtle.ConstantTimeCompare([]byte(s.token), []byte(hello.SessionToken)) != 1 {
+		return nil, nil, fmt.Errorf("no session to resume, say hello without a session token")
+	}
+	if hello.OrderID != 0 && !s.orders[hello.OrderID] {
+		return nil, nil, fmt.Errorf("order %d isn't on this robot's route anymore, say hello without a session token", hello.OrderID)
+	}
+
+	h.releaseLocked(s, clientID)
+	s.clientID = clientID
+
+	// assignments still waiting on an ack go out again with the same id so the ack still matches
+	replay := s.queued
+	s.queued = nil
+	for _, p := range h.pending {
+		if p.robotID == robotID {
+			replay = append(replay, p.frame)
+		}
+	}
+	return s, replay, nil
+}
+
+// lets go of whatever holds the session, the robot often reconnects before its old connection is noticed to be dead
+// the newest connection wins, the old one is hung up on and its unregister leaves the session alone
+func (h *Hub) releaseLocked(s *session, clientID string) {
+	if s.expiry != nil {
+		s.expiry.Stop()
+		s.expiry = nil
+	}
+	if s.clientID != "" && s.clientID != clientID {
+		if old := h.clients[s.clientID]; old != nil {
+			old.close()
+		}
+	}
+}
+
+// keeps the session of a robot that dropped mid-delivery, reports false if the robot should go offline instead
+func (h *Hub) suspend(c *Client) bool {
+	robotID := *c.RobotID
+	onRoute := h.fleet.State(robotID).OnRoute()
+
+	h.mu.Lock()
+	s := h.sessions[robotID]
+	if s == nil || s.clientID != c.ID { // someone else has taken over
+		h.mu.Unlock()
+		return s != nil
+	}
+	if !onRoute {
+		delete(h.sessions, robotID)
+		h.mu.Unlock()
+		return false
+	}
+
+	s.clientID = ""
+	token := s.token
+	s.expiry = time.AfterFunc(h.resumeWindow, func() { h.expireSession(robotID, token) })
+	if h.rClients[robotID] == c.ID {
+		delete(h.rClients, robotID)
+	}
+	h.mu.Unlock()
+
+	log.Printf("robot %s dropped mid-delivery, holding its route for %s", robotID, h.resumeWindow)
+	h.fleet.SetStale(robotID, true) // no new routes, though it can't get any on the way anyway
+	return true
+}
+
+func (h *Hub) expireSession(robotID string, token string) {
+	h.mu.Lock()
+	s := h.sessions[robotID]
+	if s == nil || s.token != token || s.clientID != "" {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.sessions, robotID)
+	h.mu.Unlock()
+
+	h.recallRoute(robotID, "robot didn't come back to resume its session")
+	h.robotGone(robotID, "didn't come back to resume its session")
+}
+
+// takes a robot that isn't connected offline, the matcher stops counting on it
+func (h *Hub) robotGone(robotID string, reason string) {
+	log.Printf("robot %s is offline: %s", robotID, reason)
+	h.fleet.SetStale(robotID, false)
+	if err := h.fleet.Transition(robotID, robots.StateOffline, reason); err != nil {
+		log.Println(err.Error())
+	}
+	h.submitRobot(matcher.NewRobotUpdate(robots.StateOffline, robotID, geo.Point{}))
+}
+
+// takes back every order the robot had on its lost session, picked up ones are failed by the matcher
+// done while the robot is still stale so none of them go straight back to it
+// blocks on the matcher, never call it from Run
+func (h *Hub) recallRoute(robotID string, reason string) {
+	if _, err := h.orm.RecallRobot(robotID, reason); err != nil {
+		log.Printf("failed recalling the orders of robot %s: %v", robotID, err)
+	}
+}
+
+// keeps the session's idea of the robot's route up to date
+func (h *Hub) routeChanged(robotID string, add []int, remove []int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.sessions[robotID]
+	if s == nil {
+		return
+	}
+	for _, orderID := range add {
+		s.orders[orderID] = true
+	}
+	for _, orderID := range remove {
+		delete(s.orders, orderID)
+	}
+}
+
+// whether the order is on the robot's route, robots only get to move their own orders along
+func (h *Hub) onRoute(robotID string, orderID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	s := h.sessions[robotID]
+	return s != nil && s.orders[orderID]
+}
+
+// the robot is done with its route
+func (h *Hub) routeDone(robotID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s := h.sessions[robotID]; s != nil {
+		clear(s.orders)
+	}
+}
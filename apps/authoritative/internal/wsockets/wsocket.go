@@ -1,60 +1,259 @@
 package wsockets
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
+		return true // robots aren't browsers, the token is what keeps strangers out
 	},
 }
 
+// Run is the only goroutine that adds or removes clients, read pumps and timers only ever look them up
+// clients, rClients, pending and sessions are all guarded by mu, whoever touches them off Run takes it
+// nothing on Run waits on the matcher (robot updates go through the outbox) and nothing a robot sends can stop it
 type Hub struct {
 	clients    map[string]*Client
-	rClients   map[string]string
+	rClients   map[string]string // robot id -> client id
 	orm        *matcher.OrderRobotMatcher
-	matches    chan (*matcher.OrderRobotMatch)
-	broadcast  chan []byte
+	fleet      *robots.Manager // what each robot is doing, shared with the matcher
+	states     *state.Manager
+	auth       *security.RobotAuth
+	matches    <-chan (*matcher.OrderRobotMatch)
 	register   chan *Client
 	unregister chan *Client
+	done       chan struct{} // closed once Run has returned
 	mu         sync.RWMutex
+	outbox     outbox
+
+	pending           map[string]*pendingAssignment // assignments waiting on the robot's ack, by frame id
+	ackTimeout        time.Duration
+	unhealthyCooldown time.Duration
+
+	pongWait          time.Duration
+	writeWait         time.Duration
+	heartbeatInterval time.Duration
+	staleAfter        int // missed heartbeats
+	offlineAfter      int
+
+	sessions     map[string]*session // by robot id
+	resumeWindow time.Duration
+
+	backpressure map[MessageClass]Backpressure
+
+	live *telemetry.Store // latest position and vitals of every robot, nil if nobody reads them
+}
+
+type HubOption func(*Hub)
+
+// how long a robot has to accept or reject an assignment before its orders go to someone else
+func WithAckTimeout(timeout time.Duration) HubOption {
+	return func(h *Hub) {
+		h.ackTimeout = timeout
+	}
+}
+
+// how long a robot that rejected or missed an assignment is kept off new routes
+func WithUnhealthyCooldown(cooldown time.Duration) HubOption {
+	return func(h *Hub) {
+		h.unhealthyCooldown = cooldown
+	}
+}
+
+// how long the connection may go without a pong (or any frame) before it is dropped, pings go out a bit more often than that
+func WithPongWait(wait time.Duration) HubOption {
+	return func(h *Hub) {
+		h.pongWait = wait
+	}
+}
+
+// how long a single write to a robot may take
+func WithWriteWait(wait time.Duration) HubOption {
+	return func(h *Hub) {
+		h.writeWait = wait
+	}
+}
+
+// how long a robot that dropped mid-delivery has to reconnect and resume before it is taken offline
+func WithResumeWindow(window time.Duration) HubOption {
+	return func(h *Hub) {
+		h.resumeWindow = window
+	}
+}
+
+// how often robots have to send a heartbeat, told to them in the welcome
+func WithHeartbeatInterval(interval time.Duration) HubOption {
+	return func(h *Hub) {
+		h.heartbeatInterval = interval
+	}
+}
+
+// after this many missed heartbeats a robot gets no new routes, after offline many it is hung up on
+func WithMissedHeartbeats(stale int, offline int) HubOption {
+	return func(h *Hub) {
+		h.staleAfter = stale
+		h.offlineAfter = offline
+	}
+}
+
+// keeps every robot's telemetry in the store for the matcher, routing and admin api to read
+func WithLiveStore(live *telemetry.Store) HubOption {
+	return func(h *Hub) {
+		h.live = live
+	}
 }
 
 type Client struct {
-	ID      string
-	RobotID *string
-	status  string
-	hub     *Hub
-	conn    *websocket.Conn
-	send    chan []byte
+	ID            string
+	RobotID       *string // set by the hello, nil until the handshake is done
+	authID        string  // the robot its token was issued to, it can't speak for any other
+	capabilities  map[string]bool
+	hub           *Hub
+	conn          *websocket.Conn
+	mu            sync.Mutex // guards the queue, closed, last, lastHeartbeat, stale and setting RobotID
+	queue         []queuedFrame
+	queued        [classCount]int // frames in the queue by class
+	failures      [classCount]int // frames in a row that had no room, by class
+	ready         chan struct{}   // nudged whenever the queue is non empty
+	room          chan struct{}   // closed and replaced whenever the write pump takes the queue
+	quit          chan struct{}   // closed once the client is closed
+	closed        bool
+	last          Telemetry // what the robot last reported, used to put it back in the matcher after a cooldown
+	lastHeartbeat time.Time // connecting counts as one
+	stale         bool
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, clientID string, authID string) *Client {
+	return &Client{
+		ID:     clientID,
+		authID: authID,
+		hub:    hub,
+		conn:   conn,
+		ready:  make(chan struct{}, 1),
+		room:   make(chan struct{}),
+		quit:   make(chan struct{}),
+
+		lastHeartbeat: time.Now(),
+	}
+}
+
+// queues a frame for the write pump, returns its id, false if the client is gone or too far behind to take it
+func (c *Client) enqueue(msgType string, payload any) (string, bool) {
+	frame, id, err := encodeFrame(msgType, payload)
+	if err != nil {
+		log.Println(err.Error())
+		return "", false
+	}
+	return id, c.push(classOf(msgType), frame)
+}
+
+func (c *Client) sendError(code string, ref string, message string) {
+	c.enqueue(TypeError, &Error{Code: code, Message: message, Ref: ref})
+}
+
+// the write pump drains whatever is still queued and hangs up
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.quit)
+	}
 }
 
-func NewHub(orm *matcher.OrderRobotMatcher, match chan (*matcher.OrderRobotMatch)) *Hub {
-	return &Hub{
+func NewHub(orm *matcher.OrderRobotMatcher, match <-chan (*matcher.OrderRobotMatch), fleet *robots.Manager, states *state.Manager, auth *security.RobotAuth, opts ...HubOption) *Hub {
+	h := &Hub{
+		fleet:      fleet,
+		states:     states,
+		auth:       auth,
 		clients:    make(map[string]*Client),
 		rClients:   make(map[string]string),
 		matches:    match,
 		orm:        orm,
-		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
+		outbox:     outbox{ready: make(chan struct{}, 1)},
 		unregister: make(chan *Client),
+		done:       make(chan struct{}),
+
+		pending:           make(map[string]*pendingAssignment),
+		ackTimeout:        DefaultAckTimeout,
+		unhealthyCooldown: DefaultUnhealthyCooldown,
+
+		pongWait:          DefaultPongWait,
+		writeWait:         DefaultWriteWait,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		staleAfter:        DefaultStaleAfter,
+		offlineAfter:      DefaultOfflineAfter,
+
+		sessions:     make(map[string]*session),
+		resumeWindow: DefaultResumeWindow,
+
+		backpressure: defaultBackpressure(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// disconnects every client, their write pumps see they're closed and hang up
+func (h *Hub) shutdown() {
+	h.mu.Lock()
+	for clientID, client := range h.clients {
+		client.close()
+		delete(h.clients, clientID)
+	}
+	for frameID, p := range h.pending { // nobody is left to requeue them, the matcher has stopped too
+		p.timer.Stop()
+		delete(h.pending, frameID)
+	}
+	for robotID, s := range h.sessions {
+		if s.expiry != nil {
+			s.expiry.Stop()
+		}
+		delete(h.sessions, robotID)
+	}
+	h.mu.Unlock()
+	close(h.done)
+	log.Println("hub stopped")
+}
+
+// runs until the matcher closes the matches channel
 func (h *Hub) Run() {
+	defer h.shutdown()
+	go h.forwardRobots()
+
+	liveness := time.NewTicker(h.heartbeatInterval)
+	defer liveness.Stop()
+
 	for {
 		select {
+		case <-liveness.C:
+			h.guard("checking heartbeats", h.checkHeartbeats)
+
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client.ID] = client
@@ -62,124 +261,374 @@ func (h *Hub) Run() {
 			log.Printf("Client connected. Total clients: %d", len(h.clients))
 
 		case client := <-h.unregister:
-			h.mu.Lock()
-			h.robotUpdate(client, &RobotUpdate{
-				Status:  "shutdown",
-				RobotID: *client.RobotID,
-			})
-			if _, ok := h.clients[client.ID]; ok {
-				delete(h.clients, client.ID)
-				close(client.send)
-			}
-			h.mu.Unlock()
+			h.guard("unregistering client "+client.ID, func() { h.disconnect(client) })
 			log.Printf("Client disconnected. Total clients: %d", len(h.clients))
 
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			for clientID, client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, clientID)
-				}
-			}
-			h.mu.RUnlock()
-		case match := <-h.matches:
-			clientID, ok := h.rClients[match.RobotID]
+		case match, ok := <-h.matches:
 			if !ok {
-				fmt.Printf("robot id is not mapped to client id, got %s", clientID)
 				return
 			}
-			h.handleMatch(match, clientID)
+			h.guard("handling a match", func() {
+				if match.Recall {
+					h.handleRecall(match)
+				} else {
+					h.handleMatch(match)
+				}
+			})
+		}
+	}
+}
 
+// a bug in one handler costs that one event, not the hub
+func (h *Hub) guard(what string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("hub recovered from a panic while %s: %v", what, r)
 		}
+	}()
+	fn()
+}
+
+// every way a connection ends comes through here, the read pump sends it once it has stopped reading
+func (h *Hub) disconnect(client *Client) {
+	if client.RobotID != nil && !h.suspend(client) {
+		h.moveRobot(client, robots.StateOffline, &Telemetry{})
+	}
+	h.mu.Lock()
+	if _, ok := h.clients[client.ID]; ok {
+		delete(h.clients, client.ID)
 	}
+	h.mu.Unlock()
+	client.close()
 }
 
-func (h *Hub) handleMatch(match *matcher.OrderRobotMatch, clientID string) {
+func (h *Hub) handleMatch(match *matcher.OrderRobotMatch) {
 	robotID := match.RobotID
 
-	rClient := h.clients[clientID]
+	h.mu.RLock()
+	rClient := h.clients[h.rClients[robotID]]
+	h.mu.RUnlock()
+
+	stops := make([]RouteStop, 0, len(match.Route))
+	orderIDs := make([]int, 0, len(match.Route))
+	for _, stop := range match.Route {
+		orderIDs = append(orderIDs, stop.OrderID)
+		stops = append(stops, RouteStop{
+			OrderID:     stop.OrderID,
+			Compartment: stop.Compartment,
+			DropoffX:    stop.Dropoff.X,
+			DropoffY:    stop.Dropoff.Y,
+		})
+	}
 
 	if rClient == nil {
 		fmt.Printf("robot does not exist %s\n", robotID)
+		go h.assignmentFailed(&pendingAssignment{robotID: robotID, orderIDs: orderIDs}, robots.AttemptUndelivered, "robot is not connected")
 		return
 	}
 
-	h.mu.RLock()
+	// anyone watching these orders now knows which robot has them
+	h.states.RobotAssigned(robotID, watchedOrders(orderIDs))
+	h.routeChanged(robotID, orderIDs, nil)
 
-	h.clients[rClient.ID].status = "delivery"
+	h.assign(rClient, robotID, orderIDs, &Assignment{
+		OrderID: match.OrderID,
+		PickupX: match.Pickup.X,
+		PickupY: match.Pickup.Y,
+		Stops:   stops,
+	})
+}
 
-	h.mu.RUnlock()
+// the matcher numbers orders with ints, the state manager with int64s
+func watchedOrders(orderIDs []int) []int64 {
+	watched := make([]int64, len(orderIDs))
+	for i, orderID := range orderIDs {
+		watched[i] = int64(orderID)
+	}
+	return watched
+}
 
-	data, err := json.Marshal(&RobotMatch{
-		RobotID: robotID,
+func (h *Hub) handleRecall(match *matcher.OrderRobotMatch) {
+	h.states.RobotReleased(match.RobotID, []int64{int64(match.OrderID)})
+	cancel := &Cancel{
 		OrderID: match.OrderID,
-	})
-	if err != nil {
-		fmt.Printf("failed to marhal match data")
+		Reason:  match.Reason,
+	}
+
+	h.mu.Lock()
+	s := h.sessions[match.RobotID]
+	if s != nil && !s.orders[match.OrderID] { // from a session the robot lost, the one it has now never got the order
+		h.mu.Unlock()
+		return
+	}
+	if s != nil {
+		delete(s.orders, match.OrderID)
+	}
+	if s != nil && s.clientID == "" { // away, it gets the cancel when it resumes
+		if frame, _, err := encodeFrame(TypeCancel, cancel); err == nil {
+			s.queued = append(s.queued, frame)
+		}
+		h.mu.Unlock()
+		return
 	}
+	clientID, ok := h.rClients[match.RobotID]
+	rClient := h.clients[clientID]
+	h.mu.Unlock()
 
-	rClient.send <- data
+	if !ok || rClient == nil {
+		// robot went away, make sure the matcher doesn't keep it as idle
+		fmt.Printf("recalled robot %s is not connected\n", match.RobotID)
+		if h.fleet.State(match.RobotID) != robots.StateOffline { // already taken offline when its session was lost
+			h.robotGone(match.RobotID, "recalled while disconnected")
+		}
+		return
+	}
+	rClient.enqueue(TypeCancel, cancel)
 }
 
-// first emit is online, then is ready
-func (h *Hub) robotUpdate(c *Client, rUpdate *RobotUpdate) {
-	status := rUpdate.Status
-	rID := &rUpdate.RobotID
+// ties the connection to the robot its token was issued to, returns false if the robot should be hung up on
+func (h *Hub) hello(c *Client, env *Envelope, hello *Hello) bool {
+	if c.RobotID != nil {
+		c.sendError(ErrCodeRejected, env.ID, "already said hello")
+		return true
+	}
+	if hello.ProtocolVersion != ProtocolVersion {
+		c.sendError(ErrCodeUnsupportedVersion, env.ID, fmt.Sprintf("server speaks protocol version %d", ProtocolVersion))
+		return false
+	}
+	if hello.RobotID != c.authID { // otherwise anyone could pose as a robot and be handed orders
+		fmt.Printf("client authenticated as robot %s claimed to be %s, hanging up\n", c.authID, hello.RobotID)
+		c.sendError(ErrCodeForbidden, env.ID, "token was issued to another robot")
+		return false
+	}
 
-	switch status {
-	case "online":
-		if c.RobotID == nil {
-			c.RobotID = rID
-			h.mu.RLock()
-			h.rClients[*rID] = c.ID
-			h.mu.RUnlock()
-		} else if c.RobotID != rID {
-			fmt.Printf("Robot ID does not match up, expected: %s got: %s", *c.RobotID, *rID)
-			return
+	c.capabilities = make(map[string]bool)
+	welcome := &Welcome{
+		ProtocolVersion:   ProtocolVersion,
+		Capabilities:      []string{},
+		HeartbeatInterval: h.heartbeatInterval.Milliseconds(),
+	}
+	for _, capability := range hello.Capabilities {
+		if slices.Contains(serverCapabilities, capability) {
+			c.capabilities[capability] = true
+			welcome.Capabilities = append(welcome.Capabilities, capability)
 		}
-		c.status = "online"
-		ormRUpdate := matcher.NewRobotUpdate(status, *rID)
-		h.orm.SubmitRobot(ormRUpdate)
-	case "shutdown":
-		if c.RobotID == nil {
-			return
+	}
+
+	robotID := hello.RobotID
+	var replay [][]byte
+	dropped := false
+
+	h.mu.Lock()
+	if hello.SessionToken != "" {
+		s, frames, err := h.resumeLocked(robotID, c.ID, hello)
+		if err != nil { // the robot can still start over with a plain hello
+			h.mu.Unlock()
+			c.sendError(ErrCodeRejected, env.ID, err.Error())
+			return true
 		}
-		h.mu.RLock()
-		delete(h.rClients, *c.RobotID)
-		h.mu.RUnlock()
+		welcome.SessionToken = s.token
+		welcome.Resumed = true
+		replay = frames
+	} else {
+		var s *session
+		s, dropped = h.newSessionLocked(robotID, c.ID)
+		welcome.SessionToken = s.token
+	}
+	h.rClients[robotID] = c.ID
+	h.mu.Unlock()
+
+	c.mu.Lock() // the heartbeat check reads it from Run
+	c.RobotID = &robotID
+	c.mu.Unlock()
+
+	if dropped { // came back without its session, whatever it had on it is lost
+		h.recallRoute(robotID, "robot reconnected without resuming its session")
+		h.robotGone(robotID, "reconnected without resuming its session")
+	}
+	if welcome.Resumed {
+		log.Printf("robot %s resumed its session, sending %d frames again", robotID, len(replay))
+		h.fleet.SetStale(robotID, false)
+	}
 
-		ormRUpdate := matcher.NewRobotUpdate(status, *rID)
-		h.orm.SubmitRobot(ormRUpdate)
+	c.enqueue(TypeWelcome, welcome)
+	for _, frame := range replay {
+		c.push(ClassAssignment, frame)
 	}
+	return true
 }
 
-func (h *Hub) handleEvents(c *Client, msg *Message) {
-	data, err := json.Marshal(msg.Payload)
+func (h *Hub) telemetry(c *Client, env *Envelope, t *Telemetry) {
+	robotState, err := robots.ParseState(t.State)
 	if err != nil {
-		fmt.Print("error marshalling payload", err)
+		c.sendError(ErrCodeBadPayload, env.ID, err.Error())
+		return
+	}
+	if !c.capabilities[CapabilityBatchedRoutes] { // one order at a time, whatever it says it has room for
+		t.Compartments, t.FreeCapacity = 0, 0
+	}
+	c.mu.Lock()
+	c.last = *t
+	c.mu.Unlock()
+	if h.live != nil && robotState != robots.StateOffline { // shutdown updates don't carry a position
+		h.live.Record(telemetry.Reading{
+			RobotID: *c.RobotID,
+			Loc:     geo.Point{X: t.X, Y: t.Y},
+			Battery: t.Battery,
+			Speed:   t.Speed,
+			Faults:  t.Faults,
+		})
 	}
+	h.moveRobot(c, robotState, t)
+}
 
-	switch msg.Type {
-	case "update":
-		var robotUpdate RobotUpdate
-		err = json.Unmarshal(data, &robotUpdate)
-		if err != nil {
-			fmt.Print("error marshalling payload", err)
+// moves the robot through the fleet's state machine and tells the matcher
+func (h *Hub) moveRobot(c *Client, robotState robots.State, t *Telemetry) {
+	rID := *c.RobotID
+
+	h.mu.Lock()
+	if robotState == robots.StateOffline {
+		delete(h.rClients, rID)
+	} else { // back after saying it was going offline
+		h.rClients[rID] = c.ID
+	}
+	h.mu.Unlock()
+
+	// sent before it got its route, letting it count as free would drop the route's orders from the matcher before the ack decides their fate
+	if robotState.Available() && h.fleet.State(rID) == robots.StateAssigned && h.awaitingAck(rID) {
+		log.Printf("robot %s says it is %s but hasn't acked its route yet, keeping it assigned", rID, robotState)
+		return
+	}
+
+	if err := h.fleet.Transition(rID, robotState, "reported by robot"); err != nil {
+		fmt.Printf("rejected update from robot %s: %v\n", rID, err)
+		if robotState != robots.StateOffline {
+			c.sendError(ErrCodeRejected, "", err.Error())
+		}
+		return
+	}
+
+	if robotState.Available() {
+		h.routeDone(rID)
+	}
+
+	loc := geo.Point{X: t.X, Y: t.Y}
+	if robotState != robots.StateOffline { // shutdown updates don't carry a position
+		h.states.RobotMoved(rID, loc)
+	}
+
+	ormRUpdate := matcher.NewRobotUpdate(robotState, rID, loc).
+		WithCapacity(t.Compartments, t.FreeCapacity)
+	h.submitRobot(ormRUpdate)
+}
+
+// statuses a robot is allowed to move an order to, the rest belong to the matcher and clients
+var robotOrderStatuses = map[state.Status]bool{
+	state.StatusPickedUp:  true,
+	state.StatusInTransit: true,
+	state.StatusArrived:   true,
+	state.StatusDelivered: true,
+	state.StatusFailed:    true,
+}
+
+func (h *Hub) progress(c *Client, env *Envelope, p *Progress) {
+	status, err := state.ParseStatus(p.Status)
+	if err != nil || !robotOrderStatuses[status] {
+		c.sendError(ErrCodeRejected, env.ID, fmt.Sprintf("robots can't set an order to %q", p.Status))
+		return
+	}
+	if !h.onRoute(*c.RobotID, int(p.OrderID)) {
+		c.sendError(ErrCodeRejected, env.ID, fmt.Sprintf("order %d isn't on this robot's route", p.OrderID))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = h.states.Apply(ctx, state.Event{OrderID: p.OrderID, To: status, Source: state.SourceRobot})
+	if err != nil {
+		fmt.Printf("rejected order update from robot %s: %v\n", *c.RobotID, err)
+		c.sendError(ErrCodeRejected, env.ID, err.Error())
+		return
+	}
+	if status == state.StatusDelivered || status == state.StatusFailed {
+		h.routeChanged(*c.RobotID, nil, []int{int(p.OrderID)})
+	}
+}
+
+// decodes one frame and hands it to its handler, returns false if the robot should be hung up on
+func (h *Hub) handleFrame(c *Client, data []byte) bool {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Type == "" {
+		c.sendError(ErrCodeBadFrame, "", "frames are json envelopes with a v and a type")
+		return true
+	}
+	if env.Version != ProtocolVersion {
+		c.sendError(ErrCodeUnsupportedVersion, env.ID, fmt.Sprintf("server speaks protocol version %d", ProtocolVersion))
+		return true
+	}
+	if c.RobotID == nil && env.Type != TypeHello {
+		c.sendError(ErrCodeHandshakeRequired, env.ID, "say hello first")
+		return true
+	}
+
+	var err error
+	switch env.Type {
+	case TypeHello:
+		var hello Hello
+		if err = decodePayload(&env, &hello); err == nil {
+			return h.hello(c, &env, &hello)
+		}
+	case TypeTelemetry:
+		var t Telemetry
+		if err = decodePayload(&env, &t); err == nil {
+			h.telemetry(c, &env, &t)
+		}
+	case TypeProgress:
+		var p Progress
+		if err = decodePayload(&env, &p); err == nil {
+			h.progress(c, &env, &p)
+		}
+	case TypeAck:
+		var ack Ack
+		if err = decodePayload(&env, &ack); err == nil {
+			h.ack(c, &env, &ack)
+		}
+	case TypeHeartbeat:
+		var hb Heartbeat
+		if err = decodePayload(&env, &hb); err == nil {
+			h.heartbeat(c, &env, &hb)
 		}
-		h.robotUpdate(c, &robotUpdate)
-		fmt.Printf("Robot %s status updated to %s\n", robotUpdate.RobotID, robotUpdate.Status)
+	case TypeError:
+		var e Error
+		if err = decodePayload(&env, &e); err == nil {
+			log.Printf("robot %s reported %s: %s", *c.RobotID, e.Code, e.Message)
+		}
+	default:
+		c.sendError(ErrCodeUnknownType, env.ID, fmt.Sprintf("unknown frame type %q", env.Type))
+		return true
+	}
+
+	if err != nil {
+		c.sendError(ErrCodeBadPayload, env.ID, err.Error())
 	}
+	return true
 }
 
 func (c *Client) readPump() {
+	// the hub closes the client on unregister, writePump then flushes what's queued (like the error that made us hang up) and closes the conn
 	defer func() {
-		c.hub.unregister <- c
-		c.conn.Close()
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done: // hub is gone, nothing left to clean up
+			c.conn.Close()
+		}
 	}()
 
+	c.conn.SetReadDeadline(time.Now().Add(c.hub.pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.hub.pongWait))
+	})
+
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
@@ -188,30 +637,66 @@ func (c *Client) readPump() {
 			}
 			break
 		}
-		log.Printf("Received: %s", message)
-		var incomingMsg Message
-		if err := json.Unmarshal(message, &incomingMsg); err != nil {
-			log.Printf("Error unmarshalling JSON: %v", err)
-			// Optionally send an error message back to the client
-			continue
+		c.conn.SetReadDeadline(time.Now().Add(c.hub.pongWait)) // any frame shows the connection is alive
+		keep := false                                          // a frame that makes the handler panic costs the robot its connection, nothing more
+		c.hub.guard("handling a frame from client "+c.ID, func() { keep = c.hub.handleFrame(c, message) })
+		if !keep {
+			break
 		}
-		c.hub.handleEvents(c, &incomingMsg)
 	}
 }
 
 func (c *Client) writePump() {
-	defer c.conn.Close()
+	ping := time.NewTicker(c.hub.pongWait * 9 / 10) // the pong has to make it back before the read deadline
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
 
-	for message := range c.send {
-		err := c.conn.WriteMessage(websocket.TextMessage, message)
-		if err != nil {
-			log.Printf("write error: %v", err)
+	for {
+		select {
+		case <-c.ready:
+			if !c.write(c.take()) {
+				return
+			}
+
+		case <-c.quit:
+			// closed by the hub, nothing more gets queued, send what is left and say goodbye properly
+			if c.write(c.take()) {
+				c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+			}
 			return
+
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("ping to client %s failed: %v", c.ID, err)
+				return
+			}
 		}
 	}
 }
 
+func (c *Client) write(frames [][]byte) bool {
+	for _, frame := range frames {
+		c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
+		if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			log.Printf("write error: %v", err)
+			return false
+		}
+	}
+	return true
+}
+
 func HandleWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	robotID, err := hub.auth.Authenticate(r)
+	if err != nil {
+		log.Printf("rejected websocket from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
@@ -222,15 +707,14 @@ func HandleWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		log.Fatalf("failed to generate UUID: %v", err)
 	}
 
-	clientID := newUUID.String()
-	client := &Client{
-		ID:   clientID,
-		hub:  hub,
-		conn: conn,
-		send: make(chan []byte, 256),
-	}
+	client := newClient(hub, conn, newUUID.String(), robotID)
 
-	client.hub.register <- client
+	select {
+	case client.hub.register <- client:
+	case <-client.hub.done:
+		conn.Close()
+		return
+	}
 
 	go client.writePump()
 	go client.readPump()
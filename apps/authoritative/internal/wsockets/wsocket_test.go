@@ -0,0 +1,680 @@
+package wsockets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/matcher"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/robots"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/security"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/state"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/internal/telemetry"
+	db "github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg"
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
+)
+
+type testHub struct {
+	url   string
+	hub   *Hub
+	store *db.MemoryStore
+	auth  *security.RobotAuth
+	fleet *robots.Manager
+	orm   *matcher.OrderRobotMatcher
+}
+
+// a hub behind a real websocket server, robot-1 and robot-2 are registered
+func newTestHub(t *testing.T, opts ...HubOption) *testHub {
+	t.Helper()
+
+	store := db.NewMemoryStore()
+	store.InsertRobot(context.Background(), db.Robot{ID: "robot-1", Status: db.RobotStatusActive})
+	store.InsertRobot(context.Background(), db.Robot{ID: "robot-2", Status: db.RobotStatusActive})
+	auth, err := security.NewRobotAuth([]byte("test-secret-that-is-long-enough-for-hmac"), store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fleet := robots.NewManager()
+	orm := matcher.CreateOrderRobotMatcher(matcher.WithRobots(fleet))
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := NewHub(orm, orm.StartORM(ctx), fleet, state.NewManager(store), auth, opts...)
+	go hub.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		HandleWebSocket(hub, w, r)
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		cancel()
+		orm.Wait()
+		<-hub.Done()
+	})
+
+	return &testHub{
+		url:   "ws" + strings.TrimPrefix(srv.URL, "http"),
+		hub:   hub,
+		store: store,
+		auth:  auth,
+		fleet: fleet,
+		orm:   orm,
+	}
+}
+
+func (h *testHub) dial(t *testing.T, robotID string) *websocket.Conn {
+	t.Helper()
+	token, _, err := h.auth.Issue(robotID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(h.url, http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		t.Fatalf("failed connecting as %s: %v", robotID, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func writeFrame(t *testing.T, conn *websocket.Conn, msgType string, payload any) {
+	t.Helper()
+	raw, _ := json.Marshal(payload)
+	if err := conn.WriteJSON(&Envelope{Version: ProtocolVersion, Type: msgType, Payload: raw}); err != nil {
+		t.Fatalf("failed writing %s frame: %v", msgType, err)
+	}
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) Envelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var env Envelope
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("failed reading frame: %v", err)
+	}
+	return env
+}
+
+// reads frames until one of the type shows up
+func expectFrame(t *testing.T, conn *websocket.Conn, msgType string, payload any) Envelope {
+	t.Helper()
+	for {
+		env := readFrame(t, conn)
+		if env.Type != msgType {
+			continue
+		}
+		if payload != nil {
+			if err := json.Unmarshal(env.Payload, payload); err != nil {
+				t.Fatalf("bad %s payload: %v", msgType, err)
+			}
+		}
+		return env
+	}
+}
+
+// connects and does the handshake
+func (h *testHub) greet(t *testing.T, robotID string, capabilities ...string) *websocket.Conn {
+	t.Helper()
+	conn := h.dial(t, robotID)
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: robotID, ProtocolVersion: ProtocolVersion, Capabilities: capabilities})
+	expectFrame(t, conn, TypeWelcome, nil)
+	return conn
+}
+
+func TestHandleWebSocketRejectsMissingToken(t *testing.T) {
+	h := newTestHub(t)
+
+	_, resp, err := websocket.DefaultDialer.Dial(h.url, nil)
+	if err == nil {
+		t.Fatal("expected the upgrade to be refused")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401, got %v", resp)
+	}
+}
+
+func TestSpoofedRobotIDIsHungUpOn(t *testing.T) {
+	h := newTestHub(t)
+	conn := h.dial(t, "robot-1")
+
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: "robot-2", ProtocolVersion: ProtocolVersion})
+
+	var e Error
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeForbidden {
+		t.Errorf("expected a forbidden error, got %+v", e)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("expected the connection to be closed")
+	}
+	if got := h.fleet.State("robot-2"); got != robots.StateOffline {
+		t.Errorf("expected robot-2 to never come online, got %s", got)
+	}
+}
+
+func TestHandshakeNegotiatesCapabilities(t *testing.T) {
+	h := newTestHub(t)
+	conn := h.dial(t, "robot-1")
+
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle"})
+	var e Error
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeHandshakeRequired {
+		t.Errorf("expected handshake_required before hello, got %+v", e)
+	}
+
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, Capabilities: []string{CapabilityBatchedRoutes, "teleport"}})
+	var welcome Welcome
+	expectFrame(t, conn, TypeWelcome, &welcome)
+	if welcome.ProtocolVersion != ProtocolVersion || len(welcome.Capabilities) != 1 || welcome.Capabilities[0] != CapabilityBatchedRoutes {
+		t.Errorf("expected only batched_routes to be agreed on, got %+v", welcome)
+	}
+}
+
+func TestUnknownTypeAndVersionGetErrorFrames(t *testing.T) {
+	h := newTestHub(t)
+	conn := h.greet(t, "robot-1")
+
+	conn.WriteJSON(&Envelope{Version: ProtocolVersion, Type: "teleport", ID: "frame-1", Payload: json.RawMessage(`{}`)})
+	var e Error
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeUnknownType || e.Ref != "frame-1" {
+		t.Errorf("expected unknown_type pointing at frame-1, got %+v", e)
+	}
+
+	conn.WriteJSON(&Envelope{Version: ProtocolVersion + 1, Type: TypeTelemetry, Payload: json.RawMessage(`{"state":"idle"}`)})
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeUnsupportedVersion {
+		t.Errorf("expected unsupported_version, got %+v", e)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeBadFrame {
+		t.Errorf("expected bad_frame, got %+v", e)
+	}
+
+	// still connected after all that
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "nonsense"})
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeBadPayload {
+		t.Errorf("expected bad_payload for an unknown state, got %+v", e)
+	}
+}
+
+// waits for the fleet to see the robot in the state
+func (h *testHub) waitForState(t *testing.T, robotID string, want robots.State) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for h.fleet.State(robotID) != want {
+		select {
+		case <-deadline:
+			t.Fatalf("%s never got to %s, is %s", robotID, want, h.fleet.State(robotID))
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestAuthenticatedRobotGetsOrders(t *testing.T) {
+	h := newTestHub(t)
+	conn := h.greet(t, "robot-1")
+
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+
+	var assignment Assignment
+	env := expectFrame(t, conn, TypeAssignment, &assignment)
+	if assignment.OrderID != 1 || len(assignment.Stops) != 1 {
+		t.Errorf("expected a route with order 1, got %+v", assignment)
+	}
+	if env.ID == "" {
+		t.Error("expected the assignment to carry an id to ack")
+	}
+}
+
+func TestTelemetryLandsInTheLiveStore(t *testing.T) {
+	live := telemetry.NewStore()
+	h := newTestHub(t, WithLiveStore(live))
+	conn := h.greet(t, "robot-1")
+
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle", X: 4, Y: 2, Battery: 65, Speed: 1.5, Faults: []string{"bumper"}})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	r, ok := live.Get("robot-1")
+	if !ok || r.Loc != (geo.Point{X: 4, Y: 2}) || r.Battery != 65 || r.Speed != 1.5 || len(r.Faults) != 1 || r.Faults[0] != "bumper" {
+		t.Errorf("expected the telemetry to be recorded, got %+v %v", r, ok)
+	}
+
+	// going offline carries no position, the last one is kept until it expires
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "offline"})
+	h.waitForState(t, "robot-1", robots.StateOffline)
+	if loc, _ := live.Position("robot-1"); loc != (geo.Point{X: 4, Y: 2}) {
+		t.Errorf("expected the last position to be kept, got %v", loc)
+	}
+}
+
+func TestRejectedAssignmentGoesToAnotherRobot(t *testing.T) {
+	h := newTestHub(t)
+	robot1 := h.greet(t, "robot-1")
+	writeFrame(t, robot1, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, matcher.PriorityExpress))
+	env := expectFrame(t, robot1, TypeAssignment, nil)
+
+	robot2 := h.greet(t, "robot-2")
+	writeFrame(t, robot2, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-2", robots.StateIdle)
+
+	writeFrame(t, robot1, TypeAck, &Ack{Ref: env.ID, Accepted: false, Reason: "battery low"})
+
+	var cancel Cancel
+	expectFrame(t, robot1, TypeCancel, &cancel)
+	if cancel.OrderID != 1 {
+		t.Errorf("expected robot-1 to be told to drop order 1, got %+v", cancel)
+	}
+	var assignment Assignment
+	expectFrame(t, robot2, TypeAssignment, &assignment)
+	if assignment.OrderID != 1 {
+		t.Errorf("expected order 1 to go to robot-2, got %+v", assignment)
+	}
+
+	if h.fleet.Healthy("robot-1") {
+		t.Error("expected robot-1 to be benched after rejecting")
+	}
+	attempts := h.fleet.Attempts("robot-1")
+	if len(attempts) != 1 || attempts[0].Outcome != robots.AttemptRejected || !strings.Contains(attempts[0].Reason, "battery low") {
+		t.Errorf("expected the rejection to be recorded, got %+v", attempts)
+	}
+
+	// the assignment isn't waiting anymore
+	writeFrame(t, robot1, TypeAck, &Ack{Ref: env.ID, Accepted: true})
+	var e Error
+	expectFrame(t, robot1, TypeError, &e)
+	if e.Code != ErrCodeRejected {
+		t.Errorf("expected a second ack to be rejected, got %+v", e)
+	}
+}
+
+func TestWatchAfterRejectedAssignmentHasNoRobot(t *testing.T) {
+	h := newTestHub(t)
+	order, _ := h.store.CreateOrder(context.Background(), db.Order{Status: db.OrderStatusQueued})
+	conn := h.greet(t, "robot-1")
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", int(order.ID), 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+	env := expectFrame(t, conn, TypeAssignment, nil)
+	writeFrame(t, conn, TypeAck, &Ack{Ref: env.ID, Accepted: false})
+	expectFrame(t, conn, TypeCancel, nil)
+
+	updates, stop, err := h.hub.states.Watch(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stop()
+	select {
+	case u := <-updates:
+		if u.RobotID != "" {
+			t.Errorf("expected the order to have no robot after robot-1 turned it down, got %+v", u)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the current state of the order")
+	}
+}
+
+func TestIdleBeforeAckKeepsTheRoute(t *testing.T) {
+	h := newTestHub(t)
+	robot1 := h.greet(t, "robot-1")
+	writeFrame(t, robot1, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+	env := expectFrame(t, robot1, TypeAssignment, nil)
+
+	// crossed paths with the assignment
+	writeFrame(t, robot1, TypeTelemetry, &Telemetry{State: "idle"})
+	time.Sleep(50 * time.Millisecond)
+	if got := h.fleet.State("robot-1"); got != robots.StateAssigned {
+		t.Errorf("expected robot-1 to stay assigned until it acks, got %s", got)
+	}
+
+	robot2 := h.greet(t, "robot-2")
+	writeFrame(t, robot2, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-2", robots.StateIdle)
+	writeFrame(t, robot1, TypeAck, &Ack{Ref: env.ID, Accepted: false})
+
+	var assignment Assignment
+	expectFrame(t, robot2, TypeAssignment, &assignment)
+	if assignment.OrderID != 1 {
+		t.Errorf("expected order 1 to be requeued and go to robot-2, got %+v", assignment)
+	}
+}
+
+func TestRobotsOnlyMoveTheirOwnOrders(t *testing.T) {
+	h := newTestHub(t)
+	// waiting at the drop-off, delivering it is a move the state machine allows
+	order, _ := h.store.CreateOrder(context.Background(), db.Order{Status: db.OrderStatusArrived})
+
+	robot1 := h.greet(t, "robot-1")
+	writeFrame(t, robot1, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+	robot2 := h.greet(t, "robot-2")
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", int(order.ID), 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+	env := expectFrame(t, robot1, TypeAssignment, nil)
+	writeFrame(t, robot1, TypeAck, &Ack{Ref: env.ID, Accepted: true})
+
+	writeFrame(t, robot2, TypeProgress, &Progress{OrderID: order.ID, Status: "delivered"})
+	var e Error
+	expectFrame(t, robot2, TypeError, &e)
+	if e.Code != ErrCodeRejected {
+		t.Errorf("expected robot-2 to be turned away, got %+v", e)
+	}
+	if o, _ := h.store.GetOrder(context.Background(), order.ID); o.Status != db.OrderStatusArrived {
+		t.Errorf("expected the order to be left alone, got %q", o.Status)
+	}
+
+	writeFrame(t, robot1, TypeProgress, &Progress{OrderID: order.ID, Status: "delivered"})
+	deadline := time.After(time.Second)
+	for {
+		o, _ := h.store.GetOrder(context.Background(), order.ID)
+		if o.Status == db.OrderStatusDelivered {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("expected robot-1 to move its own order along, got %q", o.Status)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestUnackedAssignmentTimesOut(t *testing.T) {
+	h := newTestHub(t, WithAckTimeout(50*time.Millisecond), WithUnhealthyCooldown(100*time.Millisecond))
+	conn := h.greet(t, "robot-1")
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+	expectFrame(t, conn, TypeAssignment, nil)
+
+	// never acked
+	var cancel Cancel
+	expectFrame(t, conn, TypeCancel, &cancel)
+	if cancel.OrderID != 1 {
+		t.Errorf("expected order 1 to be taken back, got %+v", cancel)
+	}
+
+	// the only robot around, it gets another go once the cooldown is over
+	var assignment Assignment
+	env := expectFrame(t, conn, TypeAssignment, &assignment)
+	if assignment.OrderID != 1 {
+		t.Errorf("expected order 1 again after the cooldown, got %+v", assignment)
+	}
+	writeFrame(t, conn, TypeAck, &Ack{Ref: env.ID, Accepted: true})
+
+	deadline := time.After(time.Second)
+	for len(h.fleet.Attempts("robot-1")) < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected both attempts to be recorded, got %+v", h.fleet.Attempts("robot-1"))
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	attempts := h.fleet.Attempts("robot-1")
+	if attempts[0].Outcome != robots.AttemptTimedOut || attempts[1].Outcome != robots.AttemptAccepted {
+		t.Errorf("expected a timeout then an accept, got %+v", attempts)
+	}
+}
+
+// waits for the hub to hang up, answering pings along the way
+func expectHangUp(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				t.Fatal("expected the hub to hang up")
+			}
+			return
+		}
+	}
+}
+
+func TestSilentRobotGoesStaleThenOffline(t *testing.T) {
+	// it ends up with a route, a short resume window keeps the hub from holding it for long once hung up on
+	h := newTestHub(t, WithHeartbeatInterval(20*time.Millisecond), WithMissedHeartbeats(2, 15), WithResumeWindow(20*time.Millisecond))
+	conn := h.greet(t, "robot-1")
+
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+	writeFrame(t, conn, TypeHeartbeat, &Heartbeat{State: "idle", Battery: 80})
+	deadline := time.After(time.Second)
+	for r, _ := h.fleet.Get("robot-1"); r.Battery != 80 || r.LastHeartbeat.IsZero(); r, _ = h.fleet.Get("robot-1") {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the heartbeat to be recorded, got %+v", r)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	deadline = time.After(time.Second)
+	for !h.fleet.Stale("robot-1") {
+		select {
+		case <-deadline:
+			t.Fatal("expected robot-1 to go stale")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	h.orm.SubmitOrder(matcher.CreateOrder("user", 1, 0, geo.Point{}, geo.Point{}, matcher.PriorityStandard))
+	time.Sleep(30 * time.Millisecond)
+	if got := h.fleet.State("robot-1"); got != robots.StateIdle {
+		t.Fatalf("expected a stale robot to get no routes, got %s", got)
+	}
+
+	writeFrame(t, conn, TypeHeartbeat, &Heartbeat{State: "idle", Battery: 79})
+	var assignment Assignment
+	env := expectFrame(t, conn, TypeAssignment, &assignment)
+	if assignment.OrderID != 1 {
+		t.Errorf("expected order 1 once the robot is back, got %+v", assignment)
+	}
+	writeFrame(t, conn, TypeAck, &Ack{Ref: env.ID, Accepted: true})
+
+	// goes quiet for good
+	expectHangUp(t, conn)
+	h.waitForState(t, "robot-1", robots.StateOffline)
+}
+
+func TestUnansweredPingsDropTheConnection(t *testing.T) {
+	// pings go out every 450ms, which leaves robot-2 50ms to pong even under -race
+	h := newTestHub(t, WithPongWait(500*time.Millisecond))
+
+	quiet := h.greet(t, "robot-1")
+	writeFrame(t, quiet, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-1", robots.StateIdle)
+
+	answering := h.greet(t, "robot-2")
+	writeFrame(t, answering, TypeTelemetry, &Telemetry{State: "idle"})
+	h.waitForState(t, "robot-2", robots.StateIdle)
+	go func() { // reading is what answers pings
+		for {
+			if _, _, err := answering.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// robot-1 never reads so never pongs
+	h.waitForState(t, "robot-1", robots.StateOffline)
+
+	time.Sleep(600 * time.Millisecond)
+	if got := h.fleet.State("robot-2"); got != robots.StateIdle {
+		t.Errorf("expected robot-2 to stay connected while it answers pings, got %s", got)
+	}
+}
+
+// gets robot-1 on its way to the pickup with the orders, returns the connection and its session token
+func (h *testHub) startDelivery(t *testing.T, orderIDs ...int) (*websocket.Conn, string) {
+	t.Helper()
+	for _, orderID := range orderIDs {
+		h.orm.SubmitOrder(matcher.CreateOrder("user", orderID, 0, geo.Point{}, geo.Point{X: 1}, matcher.PriorityStandard))
+	}
+
+	conn := h.dial(t, "robot-1")
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, Capabilities: []string{CapabilityBatchedRoutes}})
+	var welcome Welcome
+	expectFrame(t, conn, TypeWelcome, &welcome)
+	if welcome.SessionToken == "" {
+		t.Fatal("expected the welcome to carry a session token")
+	}
+
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "idle", Compartments: len(orderIDs), FreeCapacity: len(orderIDs)})
+	var assignment Assignment
+	env := expectFrame(t, conn, TypeAssignment, &assignment)
+	if len(assignment.Stops) != len(orderIDs) {
+		t.Fatalf("expected all %d orders on one route, got %+v", len(orderIDs), assignment)
+	}
+	writeFrame(t, conn, TypeAck, &Ack{Ref: env.ID, Accepted: true})
+	writeFrame(t, conn, TypeTelemetry, &Telemetry{State: "to_pickup", Compartments: len(orderIDs)})
+	h.waitForState(t, "robot-1", robots.StateEnRouteToPickup)
+	return conn, welcome.SessionToken
+}
+
+func TestRobotResumesMidDelivery(t *testing.T) {
+	h := newTestHub(t)
+	conn, token := h.startDelivery(t, 1, 2)
+
+	conn.Close()
+	deadline := time.After(time.Second)
+	for !h.fleet.Stale("robot-1") {
+		select {
+		case <-deadline:
+			t.Fatal("expected the hub to notice the dropped connection")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	// cancelled while the robot is away, it hears about it when it is back
+	if result, err := h.orm.CancelOrder(2); err != nil || result != matcher.CancelRecalled {
+		t.Fatalf("expected order 2 to be recalled, got %v (%v)", result, err)
+	}
+
+	conn = h.dial(t, "robot-1")
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, SessionToken: token, OrderID: 1})
+	var welcome Welcome
+	expectFrame(t, conn, TypeWelcome, &welcome)
+	if !welcome.Resumed || welcome.SessionToken != token {
+		t.Errorf("expected the session to be resumed, got %+v", welcome)
+	}
+	var cancel Cancel
+	expectFrame(t, conn, TypeCancel, &cancel)
+	if cancel.OrderID != 2 {
+		t.Errorf("expected the cancel for order 2 to be sent again, got %+v", cancel)
+	}
+
+	if got := h.fleet.State("robot-1"); got != robots.StateEnRouteToPickup || h.fleet.Stale("robot-1") {
+		t.Errorf("expected robot-1 to carry on to the pickup, got %s (stale %t)", got, h.fleet.Stale("robot-1"))
+	}
+	for _, tr := range h.fleet.History("robot-1") {
+		if tr.To == robots.StateOffline {
+			t.Errorf("expected robot-1 never to have gone offline, got %+v", tr)
+		}
+	}
+}
+
+func TestUnresumedSessionGoesOffline(t *testing.T) {
+	h := newTestHub(t, WithResumeWindow(100*time.Millisecond))
+	conn, token := h.startDelivery(t, 1)
+	conn.Close()
+
+	conn = h.dial(t, "robot-1")
+	var e Error
+	for _, hello := range []*Hello{
+		{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, SessionToken: "not-the-token"},
+		{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, SessionToken: token, OrderID: 5},
+	} {
+		writeFrame(t, conn, TypeHello, hello)
+		expectFrame(t, conn, TypeError, &e)
+		if e.Code != ErrCodeRejected {
+			t.Errorf("expected %+v to be turned down, got %+v", hello, e)
+		}
+	}
+
+	h.waitForState(t, "robot-1", robots.StateOffline)
+	writeFrame(t, conn, TypeHello, &Hello{RobotID: "robot-1", ProtocolVersion: ProtocolVersion, SessionToken: token, OrderID: 1})
+	expectFrame(t, conn, TypeError, &e)
+	if e.Code != ErrCodeRejected {
+		t.Errorf("expected an expired session not to be resumed, got %+v", e)
+	}
+}
+
+// the order went back in the queue, nobody else is around to take it
+func (h *testHub) waitForQueued(t *testing.T, orderID int) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		result, err := h.orm.CancelOrder(orderID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result == matcher.CancelDequeued {
+			return
+		}
+		if result == matcher.CancelRecalled {
+			t.Fatalf("expected order %d to be taken off robot-1, it still had it", orderID)
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("expected order %d back in the queue, got %v", orderID, result)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestExpiredSessionRequeuesItsOrders(t *testing.T) {
+	h := newTestHub(t, WithResumeWindow(50*time.Millisecond))
+	conn, _ := h.startDelivery(t, 1)
+	conn.Close()
+
+	h.waitForState(t, "robot-1", robots.StateOffline)
+	h.waitForQueued(t, 1)
+}
+
+func TestReconnectWithoutResumeRequeuesItsOrders(t *testing.T) {
+	h := newTestHub(t)
+	conn, _ := h.startDelivery(t, 1)
+	conn.Close()
+
+	deadline := time.After(time.Second)
+	for !h.fleet.Stale("robot-1") {
+		select {
+		case <-deadline:
+			t.Fatal("expected the hub to notice the dropped connection")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	conn = h.greet(t, "robot-1")
+	h.waitForQueued(t, 1)
+
+	// the fresh session never had the order, so there's nothing to cancel on it
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	for {
+		var env Envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			break
+		}
+		if env.Type == TypeCancel {
+			t.Errorf("expected no cancel on the fresh session, got %s", env.Payload)
+		}
+	}
+}
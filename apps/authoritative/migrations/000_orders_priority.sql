@@ -0,0 +1,3 @@
+-- the OrderPriority from order_service.proto, 0 is standard, 001 and 002 insert it
+-- runs before them so the functions they create have a column to write to
+alter table orders add column if not exists priority int not null default 0;
@@ -0,0 +1,21 @@
+-- inserts an order and all of its items in one transaction, called by db.CreateOrderWithItems
+-- a function body runs as a single statement, so if any item fails nothing is left behind
+create or replace function create_order_with_items(new_order jsonb, new_items jsonb)
+returns orders
+language plpgsql
+as $$
+declare
+  inserted orders;
+begin
+  insert into orders ("userId", "vendorId", status, priority, "dropOffLocation", "robotId")
+  select "userId", "vendorId", status, priority, "dropOffLocation", "robotId"
+  from jsonb_populate_record(null::orders, new_order)
+  returning * into inserted;
+
+  insert into "orderItems" ("orderId", "itemName", quantity, price)
+  select inserted.id, "itemName", quantity, price
+  from jsonb_populate_recordset(null::"orderItems", coalesce(new_items, '[]'::jsonb));
+
+  return inserted;
+end;
+$$;
@@ -0,0 +1,28 @@
+-- lets a retried InsertOrder find the order it already made instead of placing it twice
+-- keys are picked by callers, so they only have to be unique per user
+alter table orders add column if not exists "idempotencyKey" text;
+
+create unique index if not exists orders_user_idempotency_key
+  on orders ("userId", "idempotencyKey")
+  where "idempotencyKey" is not null;
+
+-- same as 001, now writing the key too
+create or replace function create_order_with_items(new_order jsonb, new_items jsonb)
+returns orders
+language plpgsql
+as $$
+declare
+  inserted orders;
+begin
+  insert into orders ("userId", "vendorId", status, priority, "dropOffLocation", "robotId", "idempotencyKey")
+  select "userId", "vendorId", status, priority, "dropOffLocation", "robotId", "idempotencyKey"
+  from jsonb_populate_record(null::orders, new_order)
+  returning * into inserted;
+
+  insert into "orderItems" ("orderId", "itemName", quantity, price)
+  select inserted.id, "itemName", quantity, price
+  from jsonb_populate_recordset(null::"orderItems", coalesce(new_items, '[]'::jsonb));
+
+  return inserted;
+end;
+$$;
@@ -2,8 +2,15 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jaximus808/delivery-gdg-platform/main/apps/authoritative/pkg/util/geo"
 	"github.com/supabase-community/postgrest-go"
 
 	"github.com/joho/godotenv"
@@ -17,10 +24,41 @@ func New() *Database {
 	godotenv.Load()
 	url := os.Getenv("SUPABASE_URL")
 	apiKey := os.Getenv("SUPABASE_API_KEY")
-	client := postgrest.NewClient(url, apiKey, nil)
+	if apiKey == "" { // same key the authoritative server already uses for supabase-go
+		apiKey = os.Getenv("SUPABASE_KEY")
+	}
+	// supabase serves postgrest under /rest/v1 and wants the key on every request
+	client := postgrest.NewClient(url+"/rest/v1", "", map[string]string{
+		"apikey":        apiKey,
+		"Authorization": "Bearer " + apiKey,
+	})
 	return &Database{client: client}
 }
 
+var ErrNotFound = errors.New("not found")
+
+// postgrest answers a .Single() that matched no rows with PGRST116
+func wrapNotFound(err error) error {
+	if strings.Contains(err.Error(), "PGRST116") {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
+
+var ErrAlreadyExists = errors.New("already exists")
+
+// postgres reports a unique key violation as 23505
+func wrapConflict(err error) error {
+	if strings.Contains(err.Error(), "23505") {
+		return fmt.Errorf("%w: %v", ErrAlreadyExists, err)
+	}
+	return err
+}
+
+func id64(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
 // Coordinate Type Enum
 // 1 = Vendor
 // 2 = Dropoff
@@ -33,15 +71,43 @@ const (
 )
 
 type Coordinate struct {
-	ID   string      `json:"id"`
+	ID   string      `json:"id,omitempty"` // left out on insert so the database picks one
 	X    int         `json:"x"`
 	Y    int         `json:"y"`
 	Meta interface{} `json:"meta"`
 	Type int16       `json:"type"`
 }
 
+func (c Coordinate) Point() geo.Point {
+	return geo.Point{X: c.X, Y: c.Y}
+}
+
+// Order Status Enum
+// pending, inserted but not yet picked up by the matcher
+// queued, waiting in the matcher for a robot
+// matched, handed to a robot
+// cancelled
+// picked_up, robot has it loaded at the vendor
+// in_transit, on the way to the drop-off
+// arrived, robot is waiting at the drop-off
+// delivered
+// failed, the delivery could not be completed
+// the column holds these as text, the web client reads them straight from the table
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusQueued    = "queued"
+	OrderStatusMatched   = "matched"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusPickedUp  = "picked_up"
+	OrderStatusInTransit = "in_transit"
+	OrderStatusArrived   = "arrived"
+	OrderStatusDelivered = "delivered"
+	OrderStatusFailed    = "failed"
+)
+
 type OrderItem struct {
-	ID       string  `json:"id"`
+	ID       string  `json:"id,omitempty"`
 	OrderID  int64   `json:"orderId"`
 	ItemName string  `json:"itemName"`
 	Quantity int     `json:"quantity"`
@@ -49,20 +115,31 @@ type OrderItem struct {
 }
 
 type Order struct {
-	ID              int64  `json:"id"`
+	ID              int64  `json:"id,omitempty"`
 	UserID          string `json:"userId"`
 	VendorID        string `json:"vendorId"`
-	Status          int    `json:"status"`
-	CreatedAt       string `json:"createdAt"`
-	RobotID         string `json:"robotId"`
+	Status          string `json:"status"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+	RobotID         string `json:"robotId,omitempty"` // uuid column, an empty string would be rejected
 	DropOffLocation string `json:"dropOffLocation"`
+	Priority        int    `json:"priority"`
+	IdempotencyKey  string `json:"idempotencyKey,omitempty"` // unique per user, lets a retried insert find the order it already made
 }
 
+// Robot Status Enum
+// 1 = Active, takes routes whenever it is free
+// 2 = Draining, finishes what it is carrying but gets no new routes
+
+const (
+	RobotStatusActive   = 1
+	RobotStatusDraining = 2
+)
+
 type Robot struct {
-	ID         string `json:"id"`
+	ID         string `json:"id,omitempty"` // left out on insert so the database picks one
 	Status     int    `json:"status"`
-	LastUpdate string `json:"lastUpdate"`
-	CurrentLoc string `json:"currentLoc"`
+	LastUpdate string `json:"lastUpdate,omitempty"`
+	CurrentLoc string `json:"currentLoc,omitempty"`
 }
 
 type User struct {
@@ -73,59 +150,507 @@ type User struct {
 }
 
 type Vendor struct {
-	ID          string      `json:"id"`
+	ID          string      `json:"id,omitempty"`
 	Name        string      `json:"name"`
 	Address     string      `json:"address"`
 	Hours       interface{} `json:"hours"`
 	Coordinates string      `json:"coordinates"`
 }
 
-func (db *Database) InsertCoordinate(ctx context.Context, c Coordinate) error { return nil }
+func (db *Database) InsertCoordinate(ctx context.Context, c Coordinate) error {
+	_, _, err := db.client.
+		From("coordinates").
+		Insert(c, false, "", "minimal", "").
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed inserting coordinate: %w", err)
+	}
+	return nil
+}
+
+// inserts the coordinate, or moves it if a row with its id is already there
+func (db *Database) UpsertCoordinate(ctx context.Context, c Coordinate) error {
+	_, _, err := db.client.
+		From("coordinates").
+		Insert(c, true, "id", "minimal", "").
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed upserting coordinate %s: %w", c.ID, err)
+	}
+	return nil
+}
+
 func (db *Database) GetCoordinate(ctx context.Context, id string) (Coordinate, error) {
-	return Coordinate{}, nil
+	var c Coordinate
+	_, err := db.client.
+		From("coordinates").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &c)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("failed fetching coordinate %s: %w", id, wrapNotFound(err))
+	}
+	return c, nil
 }
-func (db *Database) ListCoordinates(ctx context.Context) ([]Coordinate, error) { return nil, nil }
-func (db *Database) DeleteCoordinate(ctx context.Context, id string) error     { return nil }
 
-func (db *Database) CreateOrder(ctx context.Context, o Order) error        { return nil }
-func (db *Database) GetOrder(ctx context.Context, id int64) (Order, error) { return Order{}, nil }
-func (db *Database) ListOrdersByUser(ctx context.Context, userID string) ([]Order, error) {
-	return nil, nil
+func (db *Database) ListCoordinates(ctx context.Context) ([]Coordinate, error) {
+	var coords []Coordinate
+	_, err := db.client.
+		From("coordinates").
+		Select("*", "", false).
+		ExecuteToWithContext(ctx, &coords)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing coordinates: %w", err)
+	}
+	return coords, nil
 }
 
-func (db *Database) ListOrdersByVendor(ctx context.Context, vendorID string) ([]Order, error) {
-	return nil, nil
+func (db *Database) DeleteCoordinate(ctx context.Context, id string) error {
+	_, _, err := db.client.
+		From("coordinates").
+		Delete("minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting coordinate %s: %w", id, err)
+	}
+	return nil
+}
+
+// hands back the row as stored, with the id and createdAt the database filled in
+func (db *Database) CreateOrder(ctx context.Context, o Order) (Order, error) {
+	var rows []Order
+	_, err := db.client.
+		From("orders").
+		Insert(o, false, "", "representation", "").
+		ExecuteToWithContext(ctx, &rows)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed inserting order: %w", err)
+	}
+	if len(rows) == 0 {
+		return Order{}, errors.New("no order returned from database")
+	}
+	return rows[0], nil
+}
+
+func (db *Database) GetOrder(ctx context.Context, id int64) (Order, error) {
+	var o Order
+	_, err := db.client.
+		From("orders").
+		Select("*", "", false).
+		Eq("id", id64(id)).
+		Single().
+		ExecuteToWithContext(ctx, &o)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed fetching order %d: %w", id, wrapNotFound(err))
+	}
+	return o, nil
+}
+
+// narrows down ListOrdersByUser and ListOrdersByVendor, zero values match everything
+type ListOptions struct {
+	Statuses      []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	AfterID       int64 // for paging, only orders with a bigger id
+	Limit         int   // 0 means no limit
+}
+
+func (db *Database) ListOrdersByUser(ctx context.Context, userID string, opts ListOptions) ([]Order, error) {
+	orders, err := db.listOrders(ctx, "userId", userID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing orders of user %s: %w", userID, err)
+	}
+	return orders, nil
 }
-func (db *Database) UpdateOrderStatus(ctx context.Context, id int64, status int) error { return nil }
+
+func (db *Database) ListOrdersByVendor(ctx context.Context, vendorID string, opts ListOptions) ([]Order, error) {
+	orders, err := db.listOrders(ctx, "vendorId", vendorID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing orders of vendor %s: %w", vendorID, err)
+	}
+	return orders, nil
+}
+
+// oldest first
+func (db *Database) listOrders(ctx context.Context, column string, value string, opts ListOptions) ([]Order, error) {
+	query := db.client.
+		From("orders").
+		Select("*", "", false).
+		Eq(column, value)
+
+	if len(opts.Statuses) > 0 {
+		query = query.In("status", opts.Statuses)
+	}
+	if !opts.CreatedAfter.IsZero() {
+		query = query.Gte("createdAt", opts.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		query = query.Lt("createdAt", opts.CreatedBefore.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.AfterID > 0 {
+		query = query.Gt("id", id64(opts.AfterID))
+	}
+	query = query.Order("id", &postgrest.OrderOpts{Ascending: true})
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit, "")
+	}
+
+	var orders []Order
+	if _, err := query.ExecuteToWithContext(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// oldest first, so the matcher can rebuild its queue in the order the orders came in
+func (db *Database) ListOrdersByStatus(ctx context.Context, statuses ...string) ([]Order, error) {
+	var orders []Order
+	_, err := db.client.
+		From("orders").
+		Select("*", "", false).
+		In("status", statuses).
+		Order("id", &postgrest.OrderOpts{Ascending: true}).
+		ExecuteToWithContext(ctx, &orders)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing orders by status: %w", err)
+	}
+	return orders, nil
+}
+
+func (db *Database) UpdateOrderStatus(ctx context.Context, id int64, status string) error {
+	_, _, err := db.client.
+		From("orders").
+		Update(map[string]interface{}{"status": status}, "minimal", "").
+		Eq("id", id64(id)).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed updating status of order %d: %w", id, err)
+	}
+	return nil
+}
+
+// an empty robotID clears the robot, the order is back to waiting on one
 func (db *Database) AssignOrderToRobot(ctx context.Context, orderID int64, robotID string) error {
+	var robot interface{} = robotID
+	if robotID == "" {
+		robot = nil // uuid column, an empty string would be rejected
+	}
+	_, _, err := db.client.
+		From("orders").
+		Update(map[string]interface{}{"robotId": robot}, "minimal", "").
+		Eq("id", id64(orderID)).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed assigning order %d to robot %s: %w", orderID, robotID, err)
+	}
 	return nil
 }
-func (db *Database) DeleteOrder(ctx context.Context, id int64) error { return nil }
 
-func (db *Database) CreateOrderWithItems(ctx context.Context, order Order, items []OrderItem) error {
+// items go first, they reference the order
+func (db *Database) DeleteOrder(ctx context.Context, id int64) error {
+	_, _, err := db.client.
+		From("orderItems").
+		Delete("minimal", "").
+		Eq("orderId", id64(id)).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting items of order %d: %w", id, err)
+	}
+
+	_, _, err = db.client.
+		From("orders").
+		Delete("minimal", "").
+		Eq("id", id64(id)).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting order %d: %w", id, err)
+	}
+	return nil
+}
+
+// writes the order and its items in one transaction through the create_order_with_items function (migrations/001)
+// so a failed item never leaves an order behind without it
+func (db *Database) CreateOrderWithItems(ctx context.Context, order Order, items []OrderItem) (Order, error) {
+	if items == nil {
+		items = []OrderItem{} // the function wants an array, not null
+	}
+
+	// postgrest-go's rpc doesn't take a context, at least don't start one that's already cancelled
+	if err := ctx.Err(); err != nil {
+		return Order{}, err
+	}
+
+	body, err := db.client.RpcWithError("create_order_with_items", "", map[string]interface{}{
+		"new_order": order,
+		"new_items": items,
+	})
+	if err != nil {
+		return Order{}, fmt.Errorf("failed inserting order with items: %w", err)
+	}
+
+	// errors come back as a 4xx with a json body instead of an error
+	var rpcErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(body), &rpcErr); err == nil && rpcErr.Message != "" {
+		return Order{}, fmt.Errorf("failed inserting order with items: %w", wrapConflict(fmt.Errorf("%s (%s)", rpcErr.Message, rpcErr.Code)))
+	}
+
+	var inserted Order
+	if err := json.Unmarshal([]byte(body), &inserted); err != nil {
+		return Order{}, fmt.Errorf("failed reading inserted order: %w", err)
+	}
+	if inserted.ID == 0 {
+		return Order{}, errors.New("no order returned from database")
+	}
+	return inserted, nil
+}
+
+func (db *Database) GetOrderByIdempotencyKey(ctx context.Context, userID string, key string) (Order, error) {
+	var o Order
+	_, err := db.client.
+		From("orders").
+		Select("*", "", false).
+		Eq("userId", userID).
+		Eq("idempotencyKey", key).
+		Single().
+		ExecuteToWithContext(ctx, &o)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed fetching order with idempotency key %s: %w", key, wrapNotFound(err))
+	}
+	return o, nil
+}
+
+// frees the key up for a new order once the old one is too old to be a retry
+func (db *Database) ClearIdempotencyKey(ctx context.Context, orderID int64) error {
+	_, _, err := db.client.
+		From("orders").
+		Update(map[string]interface{}{"idempotencyKey": nil}, "minimal", "").
+		Eq("id", id64(orderID)).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed clearing idempotency key of order %d: %w", orderID, err)
+	}
+	return nil
+}
+
+func (db *Database) AddOrderItem(ctx context.Context, item OrderItem) error {
+	_, _, err := db.client.
+		From("orderItems").
+		Insert(item, false, "", "minimal", "").
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed inserting item of order %d: %w", item.OrderID, err)
+	}
 	return nil
 }
 
-func (db *Database) AddOrderItem(ctx context.Context, item OrderItem) error { return nil }
 func (db *Database) GetOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
-	return nil, nil
+	var items []OrderItem
+	_, err := db.client.
+		From("orderItems").
+		Select("*", "", false).
+		Eq("orderId", id64(orderID)).
+		ExecuteToWithContext(ctx, &items)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching items of order %d: %w", orderID, err)
+	}
+	return items, nil
+}
+
+func (db *Database) DeleteOrderItem(ctx context.Context, id string) error {
+	_, _, err := db.client.
+		From("orderItems").
+		Delete("minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting order item %s: %w", id, err)
+	}
+	return nil
+}
+
+func (db *Database) InsertRobot(ctx context.Context, r Robot) (Robot, error) {
+	var rows []Robot
+	_, err := db.client.
+		From("robots").
+		Insert(r, false, "", "representation", "").
+		ExecuteToWithContext(ctx, &rows)
+	if err != nil {
+		return Robot{}, fmt.Errorf("failed inserting robot: %w", wrapConflict(err))
+	}
+	if len(rows) == 0 {
+		return Robot{}, errors.New("no robot returned from database")
+	}
+	return rows[0], nil
+}
+
+func (db *Database) GetRobot(ctx context.Context, id string) (Robot, error) {
+	var r Robot
+	_, err := db.client.
+		From("robots").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &r)
+	if err != nil {
+		return Robot{}, fmt.Errorf("failed fetching robot %s: %w", id, wrapNotFound(err))
+	}
+	return r, nil
+}
+
+func (db *Database) SetRobotStatus(ctx context.Context, id string, status int) error {
+	_, _, err := db.client.
+		From("robots").
+		Update(map[string]interface{}{
+			"status":     status,
+			"lastUpdate": time.Now().UTC().Format(time.RFC3339Nano),
+		}, "minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed updating status of robot %s: %w", id, err)
+	}
+	return nil
 }
-func (db *Database) DeleteOrderItem(ctx context.Context, id string) error { return nil }
 
-func (db *Database) GetRobot(ctx context.Context, id string) (Robot, error)          { return Robot{}, nil }
-func (db *Database) SetRobotStatus(ctx context.Context, id string, status int) error { return nil }
 func (db *Database) UpdateRobotLocation(ctx context.Context, id string, coordinateID string) error {
+	_, _, err := db.client.
+		From("robots").
+		Update(map[string]interface{}{
+			"currentLoc": coordinateID,
+			"lastUpdate": time.Now().UTC().Format(time.RFC3339Nano),
+		}, "minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed updating location of robot %s: %w", id, err)
+	}
 	return nil
 }
-func (db *Database) ListRobots(ctx context.Context) ([]Robot, error)  { return nil, nil }
-func (db *Database) DeleteRobot(ctx context.Context, id string) error { return nil }
 
-func (db *Database) InsertUser(ctx context.Context, u User) error         { return nil }
-func (db *Database) GetUser(ctx context.Context, id string) (User, error) { return User{}, nil }
-func (db *Database) ListUsers(ctx context.Context) ([]User, error)        { return nil, nil }
-func (db *Database) DeleteUser(ctx context.Context, id string) error      { return nil }
+func (db *Database) ListRobots(ctx context.Context) ([]Robot, error) {
+	var robots []Robot
+	_, err := db.client.
+		From("robots").
+		Select("*", "", false).
+		Order("id", &postgrest.OrderOpts{Ascending: true}).
+		ExecuteToWithContext(ctx, &robots)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing robots: %w", err)
+	}
+	return robots, nil
+}
+
+func (db *Database) DeleteRobot(ctx context.Context, id string) error {
+	_, _, err := db.client.
+		From("robots").
+		Delete("minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting robot %s: %w", id, err)
+	}
+	return nil
+}
+
+func (db *Database) InsertUser(ctx context.Context, u User) error {
+	_, _, err := db.client.
+		From("users").
+		Insert(u, false, "", "minimal", "").
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed inserting user: %w", err)
+	}
+	return nil
+}
+
+func (db *Database) GetUser(ctx context.Context, id string) (User, error) {
+	var u User
+	_, err := db.client.
+		From("users").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &u)
+	if err != nil {
+		return User{}, fmt.Errorf("failed fetching user %s: %w", id, wrapNotFound(err))
+	}
+	return u, nil
+}
 
-func (db *Database) InsertVendor(ctx context.Context, v Vendor) error         { return nil }
-func (db *Database) GetVendor(ctx context.Context, id string) (Vendor, error) { return Vendor{}, nil }
-func (db *Database) ListVendors(ctx context.Context) ([]Vendor, error)        { return nil, nil }
-func (db *Database) DeleteVendor(ctx context.Context, id string) error        { return nil }
+func (db *Database) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	_, err := db.client.
+		From("users").
+		Select("*", "", false).
+		ExecuteToWithContext(ctx, &users)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing users: %w", err)
+	}
+	return users, nil
+}
+
+func (db *Database) DeleteUser(ctx context.Context, id string) error {
+	_, _, err := db.client.
+		From("users").
+		Delete("minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting user %s: %w", id, err)
+	}
+	return nil
+}
+
+func (db *Database) InsertVendor(ctx context.Context, v Vendor) error {
+	_, _, err := db.client.
+		From("vendors").
+		Insert(v, false, "", "minimal", "").
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed inserting vendor: %w", err)
+	}
+	return nil
+}
+
+func (db *Database) GetVendor(ctx context.Context, id string) (Vendor, error) {
+	var v Vendor
+	_, err := db.client.
+		From("vendors").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &v)
+	if err != nil {
+		return Vendor{}, fmt.Errorf("failed fetching vendor %s: %w", id, wrapNotFound(err))
+	}
+	return v, nil
+}
+
+func (db *Database) ListVendors(ctx context.Context) ([]Vendor, error) {
+	var vendors []Vendor
+	_, err := db.client.
+		From("vendors").
+		Select("*", "", false).
+		ExecuteToWithContext(ctx, &vendors)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing vendors: %w", err)
+	}
+	return vendors, nil
+}
+
+func (db *Database) DeleteVendor(ctx context.Context, id string) error {
+	_, _, err := db.client.
+		From("vendors").
+		Delete("minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed deleting vendor %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package db
+
+// in process stand-in for the database so handlers can be tested without supabase
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// behaves like postgrest where it matters: gets of missing rows fail with ErrNotFound, updates of missing rows do nothing
+type MemoryStore struct {
+	mu          sync.Mutex
+	coordinates map[string]Coordinate
+	orders      map[int64]Order
+	orderItems  map[string]OrderItem
+	robots      map[string]Robot
+	users       map[string]User
+	vendors     map[string]Vendor
+	nextOrderID int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		coordinates: make(map[string]Coordinate),
+		orders:      make(map[int64]Order),
+		orderItems:  make(map[string]OrderItem),
+		robots:      make(map[string]Robot),
+		users:       make(map[string]User),
+		vendors:     make(map[string]Vendor),
+		nextOrderID: 1,
+	}
+}
+
+func newID(id string) string {
+	if id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
+
+func sortedValues[K comparable, V any](m map[K]V, cmp func(a, b V) int) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, cmp)
+	return out
+}
+
+func byOrderID(a, b Order) int { return cmp.Compare(a.ID, b.ID) }
+
+func (m *MemoryStore) InsertCoordinate(ctx context.Context, c Coordinate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c.ID = newID(c.ID)
+	m.coordinates[c.ID] = c
+	return nil
+}
+
+func (m *MemoryStore) UpsertCoordinate(ctx context.Context, c Coordinate) error {
+	return m.InsertCoordinate(ctx, c)
+}
+
+func (m *MemoryStore) GetCoordinate(ctx context.Context, id string) (Coordinate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.coordinates[id]
+	if !ok {
+		return Coordinate{}, fmt.Errorf("failed fetching coordinate %s: %w", id, ErrNotFound)
+	}
+	return c, nil
+}
+
+func (m *MemoryStore) ListCoordinates(ctx context.Context) ([]Coordinate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedValues(m.coordinates, func(a, b Coordinate) int { return strings.Compare(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryStore) DeleteCoordinate(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.coordinates, id)
+	return nil
+}
+
+func (m *MemoryStore) CreateOrder(ctx context.Context, o Order) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o.ID = m.nextOrderID
+	m.nextOrderID++
+	o.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	m.orders[o.ID] = o
+	return o, nil
+}
+
+func (m *MemoryStore) GetOrder(ctx context.Context, id int64) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return Order{}, fmt.Errorf("failed fetching order %d: %w", id, ErrNotFound)
+	}
+	return o, nil
+}
+
+func (m *MemoryStore) listOrders(keep func(o Order) bool) []Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Order
+	for _, o := range sortedValues(m.orders, byOrderID) {
+		if keep(o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func (m *MemoryStore) ListOrdersByUser(ctx context.Context, userID string, opts ListOptions) ([]Order, error) {
+	return opts.apply(m.listOrders(func(o Order) bool { return o.UserID == userID })), nil
+}
+
+func (m *MemoryStore) ListOrdersByVendor(ctx context.Context, vendorID string, opts ListOptions) ([]Order, error) {
+	return opts.apply(m.listOrders(func(o Order) bool { return o.VendorID == vendorID })), nil
+}
+
+// same filtering the database does, orders are already sorted by id
+func (opts ListOptions) apply(orders []Order) []Order {
+	var out []Order
+	for _, o := range orders {
+		if len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, o.Status) {
+			continue
+		}
+		if o.ID <= opts.AfterID {
+			continue
+		}
+		createdAt, _ := time.Parse(time.RFC3339Nano, o.CreatedAt)
+		if !opts.CreatedAfter.IsZero() && createdAt.Before(opts.CreatedAfter) {
+			continue
+		}
+		if !opts.CreatedBefore.IsZero() && !createdAt.Before(opts.CreatedBefore) {
+			continue
+		}
+		out = append(out, o)
+		if opts.Limit > 0 && len(out) == opts.Limit {
+			break
+		}
+	}
+	return out
+}
+
+func (m *MemoryStore) ListOrdersByStatus(ctx context.Context, statuses ...string) ([]Order, error) {
+	return m.listOrders(func(o Order) bool { return slices.Contains(statuses, o.Status) }), nil
+}
+
+func (m *MemoryStore) UpdateOrderStatus(ctx context.Context, id int64, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[id]; ok {
+		o.Status = status
+		m.orders[id] = o
+	}
+	return nil
+}
+
+func (m *MemoryStore) AssignOrderToRobot(ctx context.Context, orderID int64, robotID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok {
+		o.RobotID = robotID
+		m.orders[orderID] = o
+	}
+	return nil
+}
+
+func (m *MemoryStore) DeleteOrder(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for itemID, item := range m.orderItems {
+		if item.OrderID == id {
+			delete(m.orderItems, itemID)
+		}
+	}
+	delete(m.orders, id)
+	return nil
+}
+
+// everything happens under one lock, so like the database function it is all or nothing
+func (m *MemoryStore) CreateOrderWithItems(ctx context.Context, order Order, items []OrderItem) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if order.IdempotencyKey != "" {
+		for _, o := range m.orders {
+			if o.UserID == order.UserID && o.IdempotencyKey == order.IdempotencyKey {
+				return Order{}, fmt.Errorf("failed inserting order with items: %w", ErrAlreadyExists)
+			}
+		}
+	}
+
+	order.ID = m.nextOrderID
+	m.nextOrderID++
+	order.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	m.orders[order.ID] = order
+
+	for _, item := range items {
+		item.ID = newID(item.ID)
+		item.OrderID = order.ID
+		m.orderItems[item.ID] = item
+	}
+	return order, nil
+}
+
+func (m *MemoryStore) GetOrderByIdempotencyKey(ctx context.Context, userID string, key string) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, o := range m.orders {
+		if o.UserID == userID && o.IdempotencyKey == key {
+			return o, nil
+		}
+	}
+	return Order{}, fmt.Errorf("failed fetching order with idempotency key %s: %w", key, ErrNotFound)
+}
+
+func (m *MemoryStore) ClearIdempotencyKey(ctx context.Context, orderID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok {
+		o.IdempotencyKey = ""
+		m.orders[orderID] = o
+	}
+	return nil
+}
+
+func (m *MemoryStore) AddOrderItem(ctx context.Context, item OrderItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.orders[item.OrderID]; !ok { // foreign key on orderId
+		return fmt.Errorf("failed inserting item of order %d: %w", item.OrderID, ErrNotFound)
+	}
+	item.ID = newID(item.ID)
+	m.orderItems[item.ID] = item
+	return nil
+}
+
+func (m *MemoryStore) GetOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []OrderItem
+	for _, item := range sortedValues(m.orderItems, func(a, b OrderItem) int { return strings.Compare(a.ID, b.ID) }) {
+		if item.OrderID == orderID {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (m *MemoryStore) DeleteOrderItem(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.orderItems, id)
+	return nil
+}
+
+func (m *MemoryStore) InsertRobot(ctx context.Context, r Robot) (Robot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r.ID = newID(r.ID)
+	if _, ok := m.robots[r.ID]; ok {
+		return Robot{}, fmt.Errorf("failed inserting robot: %w", ErrAlreadyExists)
+	}
+	r.LastUpdate = time.Now().UTC().Format(time.RFC3339Nano)
+	m.robots[r.ID] = r
+	return r, nil
+}
+
+func (m *MemoryStore) GetRobot(ctx context.Context, id string) (Robot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.robots[id]
+	if !ok {
+		return Robot{}, fmt.Errorf("failed fetching robot %s: %w", id, ErrNotFound)
+	}
+	return r, nil
+}
+
+func (m *MemoryStore) SetRobotStatus(ctx context.Context, id string, status int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.robots[id]; ok {
+		r.Status = status
+		r.LastUpdate = time.Now().UTC().Format(time.RFC3339Nano)
+		m.robots[id] = r
+	}
+	return nil
+}
+
+func (m *MemoryStore) UpdateRobotLocation(ctx context.Context, id string, coordinateID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.robots[id]; ok {
+		r.CurrentLoc = coordinateID
+		r.LastUpdate = time.Now().UTC().Format(time.RFC3339Nano)
+		m.robots[id] = r
+	}
+	return nil
+}
+
+func (m *MemoryStore) ListRobots(ctx context.Context) ([]Robot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedValues(m.robots, func(a, b Robot) int { return strings.Compare(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryStore) DeleteRobot(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.robots, id)
+	return nil
+}
+
+func (m *MemoryStore) InsertUser(ctx context.Context, u User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u.ID = newID(u.ID)
+	m.users[u.ID] = u
+	return nil
+}
+
+func (m *MemoryStore) GetUser(ctx context.Context, id string) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("failed fetching user %s: %w", id, ErrNotFound)
+	}
+	return u, nil
+}
+
+func (m *MemoryStore) ListUsers(ctx context.Context) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedValues(m.users, func(a, b User) int { return strings.Compare(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryStore) DeleteUser(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users, id)
+	return nil
+}
+
+func (m *MemoryStore) InsertVendor(ctx context.Context, v Vendor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v.ID = newID(v.ID)
+	m.vendors[v.ID] = v
+	return nil
+}
+
+func (m *MemoryStore) GetVendor(ctx context.Context, id string) (Vendor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.vendors[id]
+	if !ok {
+		return Vendor{}, fmt.Errorf("failed fetching vendor %s: %w", id, ErrNotFound)
+	}
+	return v, nil
+}
+
+func (m *MemoryStore) ListVendors(ctx context.Context) ([]Vendor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedValues(m.vendors, func(a, b Vendor) int { return strings.Compare(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryStore) DeleteVendor(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vendors, id)
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import "context"
+
+// everything the authoritative server reads and writes, *Database talks to supabase and MemoryStore keeps it in process for tests
+type Store interface {
+	InsertCoordinate(ctx context.Context, c Coordinate) error
+	UpsertCoordinate(ctx context.Context, c Coordinate) error
+	GetCoordinate(ctx context.Context, id string) (Coordinate, error)
+	ListCoordinates(ctx context.Context) ([]Coordinate, error)
+	DeleteCoordinate(ctx context.Context, id string) error
+
+	CreateOrder(ctx context.Context, o Order) (Order, error)
+	GetOrder(ctx context.Context, id int64) (Order, error)
+	ListOrdersByUser(ctx context.Context, userID string, opts ListOptions) ([]Order, error)
+	ListOrdersByVendor(ctx context.Context, vendorID string, opts ListOptions) ([]Order, error)
+	ListOrdersByStatus(ctx context.Context, statuses ...string) ([]Order, error)
+	UpdateOrderStatus(ctx context.Context, id int64, status string) error
+	AssignOrderToRobot(ctx context.Context, orderID int64, robotID string) error
+	DeleteOrder(ctx context.Context, id int64) error
+	CreateOrderWithItems(ctx context.Context, order Order, items []OrderItem) (Order, error)
+	GetOrderByIdempotencyKey(ctx context.Context, userID string, key string) (Order, error)
+	ClearIdempotencyKey(ctx context.Context, orderID int64) error
+
+	AddOrderItem(ctx context.Context, item OrderItem) error
+	GetOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error)
+	DeleteOrderItem(ctx context.Context, id string) error
+
+	InsertRobot(ctx context.Context, r Robot) (Robot, error)
+	GetRobot(ctx context.Context, id string) (Robot, error)
+	SetRobotStatus(ctx context.Context, id string, status int) error
+	UpdateRobotLocation(ctx context.Context, id string, coordinateID string) error
+	ListRobots(ctx context.Context) ([]Robot, error)
+	DeleteRobot(ctx context.Context, id string) error
+
+	InsertUser(ctx context.Context, u User) error
+	GetUser(ctx context.Context, id string) (User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	InsertVendor(ctx context.Context, v Vendor) error
+	GetVendor(ctx context.Context, id string) (Vendor, error)
+	ListVendors(ctx context.Context) ([]Vendor, error)
+	DeleteVendor(ctx context.Context, id string) error
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
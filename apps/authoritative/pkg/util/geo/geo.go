@@ -1,3 +1,16 @@
 package geo
 
 // geofencing utils or other geo spacial utils
+
+import "math"
+
+// Point is a position on the campus grid, same units as db.Coordinate
+type Point struct {
+	X int
+	Y int
+}
+
+// Distance is the straight line distance between two points
+func Distance(a, b Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
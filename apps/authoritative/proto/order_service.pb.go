@@ -1,4 +1,4 @@
-// protoc --go_out=. --go-grpc_out=. proto/order_service.proto
+// apps/authoritative: protoc --go_out=. --go-grpc_out=. proto/order_service.proto
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
@@ -24,17 +24,70 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// ----------DATA----------
+type OrderPriority int32
+
+const (
+	OrderPriority_ORDER_PRIORITY_STANDARD  OrderPriority = 0
+	OrderPriority_ORDER_PRIORITY_EXPRESS   OrderPriority = 1 //paid for faster delivery
+	OrderPriority_ORDER_PRIORITY_STAFF     OrderPriority = 2 //placed by campus staff
+	OrderPriority_ORDER_PRIORITY_SCHEDULED OrderPriority = 3 //not needed right away
+)
+
+// Enum value maps for OrderPriority.
+var (
+	OrderPriority_name = map[int32]string{
+		0: "ORDER_PRIORITY_STANDARD",
+		1: "ORDER_PRIORITY_EXPRESS",
+		2: "ORDER_PRIORITY_STAFF",
+		3: "ORDER_PRIORITY_SCHEDULED",
+	}
+	OrderPriority_value = map[string]int32{
+		"ORDER_PRIORITY_STANDARD":  0,
+		"ORDER_PRIORITY_EXPRESS":   1,
+		"ORDER_PRIORITY_STAFF":     2,
+		"ORDER_PRIORITY_SCHEDULED": 3,
+	}
+)
+
+func (x OrderPriority) Enum() *OrderPriority {
+	p := new(OrderPriority)
+	*p = x
+	return p
+}
+
+func (x OrderPriority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderPriority) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_order_service_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderPriority) Type() protoreflect.EnumType {
+	return &file_proto_order_service_proto_enumTypes[0]
+}
+
+func (x OrderPriority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderPriority.Descriptor instead.
+func (OrderPriority) EnumDescriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{0}
+}
+
+// ----------DATA----------//
 type Order struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                     //what user placed this order?
-	VendorId      string                 `protobuf:"bytes,3,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`               //who is this order for?
-	Items         []*OrderItem           `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`                                     //what items is in this order
-	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`                                   //just created
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`            //when did this order get placed?
-	DropoffLocId  string                 `protobuf:"bytes,7,opt,name=dropoff_loc_id,json=dropoffLocId,proto3" json:"dropoff_loc_id,omitempty"` //where does user want robot to drop off?
-	RobotId       string                 `protobuf:"bytes,8,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         //what user placed this order?
+	VendorId      string                 `protobuf:"bytes,3,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`                   //who is this order for?
+	Items         []*OrderItem           `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`                                         //what items is in this order
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`                                       //created, queued, matched, picked_up, in_transit, arrived, delivered, cancelled or failed
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                //when did this order get placed?
+	DropoffLocId  string                 `protobuf:"bytes,7,opt,name=dropoff_loc_id,json=dropoffLocId,proto3" json:"dropoff_loc_id,omitempty"`     //where does user want robot to drop off?
+	RobotId       string                 `protobuf:"bytes,8,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`                      //default = null until assigned a robot
+	Priority      OrderPriority          `protobuf:"varint,9,opt,name=priority,proto3,enum=order_service.OrderPriority" json:"priority,omitempty"` //how soon does this need a robot?
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -125,6 +178,13 @@ func (x *Order) GetRobotId() string {
 	return ""
 }
 
+func (x *Order) GetPriority() OrderPriority {
+	if x != nil {
+		return x.Priority
+	}
+	return OrderPriority_ORDER_PRIORITY_STANDARD
+}
+
 type OrderItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ItemId        int64                  `protobuf:"varint,1,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
@@ -193,28 +253,29 @@ func (x *OrderItem) GetPrice() float64 {
 	return 0
 }
 
-// --------REQUESTS-------
-type InsertOrderRequest struct {
+// where a robot is on the campus grid
+type RobotPosition struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InsertOrderRequest) Reset() {
-	*x = InsertOrderRequest{}
+func (x *RobotPosition) Reset() {
+	*x = RobotPosition{}
 	mi := &file_proto_order_service_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InsertOrderRequest) String() string {
+func (x *RobotPosition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InsertOrderRequest) ProtoMessage() {}
+func (*RobotPosition) ProtoMessage() {}
 
-func (x *InsertOrderRequest) ProtoReflect() protoreflect.Message {
+func (x *RobotPosition) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_order_service_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -226,39 +287,51 @@ func (x *InsertOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InsertOrderRequest.ProtoReflect.Descriptor instead.
-func (*InsertOrderRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use RobotPosition.ProtoReflect.Descriptor instead.
+func (*RobotPosition) Descriptor() ([]byte, []int) {
 	return file_proto_order_service_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *InsertOrderRequest) GetOrder() *Order {
+func (x *RobotPosition) GetX() int32 {
 	if x != nil {
-		return x.Order
+		return x.X
 	}
-	return nil
+	return 0
 }
 
-type DeleteOrderRequest struct {
+func (x *RobotPosition) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// one message per change, each carries everything known about the order so far
+type OrderUpdate struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	RobotId       string                 `protobuf:"bytes,3,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`                   //empty until a robot has it
+	RobotPosition *RobotPosition         `protobuf:"bytes,4,opt,name=robot_position,json=robotPosition,proto3" json:"robot_position,omitempty"` //unset until the robot reports in
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteOrderRequest) Reset() {
-	*x = DeleteOrderRequest{}
+func (x *OrderUpdate) Reset() {
+	*x = OrderUpdate{}
 	mi := &file_proto_order_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteOrderRequest) String() string {
+func (x *OrderUpdate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteOrderRequest) ProtoMessage() {}
+func (*OrderUpdate) ProtoMessage() {}
 
-func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
+func (x *OrderUpdate) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_order_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -270,41 +343,81 @@ func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteOrderRequest.ProtoReflect.Descriptor instead.
-func (*DeleteOrderRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use OrderUpdate.ProtoReflect.Descriptor instead.
+func (*OrderUpdate) Descriptor() ([]byte, []int) {
 	return file_proto_order_service_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *DeleteOrderRequest) GetOrder() *Order {
+func (x *OrderUpdate) GetOrderId() int64 {
 	if x != nil {
-		return x.Order
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderUpdate) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OrderUpdate) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+func (x *OrderUpdate) GetRobotPosition() *RobotPosition {
+	if x != nil {
+		return x.RobotPosition
 	}
 	return nil
 }
 
-// ---------RESPONSES----------
-type InsertOrderResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
-	ReturnMsg     string                 `protobuf:"bytes,2,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *OrderUpdate) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
 }
 
-func (x *InsertOrderResponse) Reset() {
-	*x = InsertOrderResponse{}
+type Robot struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RobotId        string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	State          string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"` //live state: offline, idle, assigned, to_pickup, loading, to_dropoff, delivering, returning or faulted
+	StateSince     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=state_since,json=stateSince,proto3" json:"state_since,omitempty"`
+	Draining       bool                   `protobuf:"varint,4,opt,name=draining,proto3" json:"draining,omitempty"`
+	Registered     bool                   `protobuf:"varint,5,opt,name=registered,proto3" json:"registered,omitempty"`                          //false for robots that connected without a row in the robots table
+	CurrentLocId   string                 `protobuf:"bytes,6,opt,name=current_loc_id,json=currentLocId,proto3" json:"current_loc_id,omitempty"` //last location written to the database
+	LastUpdate     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_update,json=lastUpdate,proto3" json:"last_update,omitempty"`
+	UnhealthyUntil *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=unhealthy_until,json=unhealthyUntil,proto3" json:"unhealthy_until,omitempty"` //set while the robot sits out a rejected or unanswered assignment
+	Stale          bool                   `protobuf:"varint,9,opt,name=stale,proto3" json:"stale,omitempty"`                                        //missed its heartbeats, gets no new routes until the next one
+	Battery        int32                  `protobuf:"varint,10,opt,name=battery,proto3" json:"battery,omitempty"`                                   //percent, from the last heartbeat or telemetry, whichever is newer
+	LastHeartbeat  *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=last_heartbeat,json=lastHeartbeat,proto3" json:"last_heartbeat,omitempty"`
+	Position       *RobotPosition         `protobuf:"bytes,12,opt,name=position,proto3" json:"position,omitempty"`                       //from the robot's telemetry, unset if it hasn't reported lately
+	Speed          float64                `protobuf:"fixed64,13,opt,name=speed,proto3" json:"speed,omitempty"`                           //grid cells per second
+	Faults         []string               `protobuf:"bytes,14,rep,name=faults,proto3" json:"faults,omitempty"`                           //sensor faults from the robot's telemetry
+	PositionAt     *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=position_at,json=positionAt,proto3" json:"position_at,omitempty"` //when the robot last reported its position
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Robot) Reset() {
+	*x = Robot{}
 	mi := &file_proto_order_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InsertOrderResponse) String() string {
+func (x *Robot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InsertOrderResponse) ProtoMessage() {}
+func (*Robot) ProtoMessage() {}
 
-func (x *InsertOrderResponse) ProtoReflect() protoreflect.Message {
+func (x *Robot) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_order_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -316,46 +429,140 @@ func (x *InsertOrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InsertOrderResponse.ProtoReflect.Descriptor instead.
-func (*InsertOrderResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Robot.ProtoReflect.Descriptor instead.
+func (*Robot) Descriptor() ([]byte, []int) {
 	return file_proto_order_service_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *InsertOrderResponse) GetOrder() *Order {
+func (x *Robot) GetRobotId() string {
 	if x != nil {
-		return x.Order
+		return x.RobotId
+	}
+	return ""
+}
+
+func (x *Robot) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Robot) GetStateSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StateSince
 	}
 	return nil
 }
 
-func (x *InsertOrderResponse) GetReturnMsg() string {
+func (x *Robot) GetDraining() bool {
 	if x != nil {
-		return x.ReturnMsg
+		return x.Draining
+	}
+	return false
+}
+
+func (x *Robot) GetRegistered() bool {
+	if x != nil {
+		return x.Registered
+	}
+	return false
+}
+
+func (x *Robot) GetCurrentLocId() string {
+	if x != nil {
+		return x.CurrentLocId
 	}
 	return ""
 }
 
-type DeleteOrderResponse struct {
+func (x *Robot) GetLastUpdate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUpdate
+	}
+	return nil
+}
+
+func (x *Robot) GetUnhealthyUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UnhealthyUntil
+	}
+	return nil
+}
+
+func (x *Robot) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *Robot) GetBattery() int32 {
+	if x != nil {
+		return x.Battery
+	}
+	return 0
+}
+
+func (x *Robot) GetLastHeartbeat() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHeartbeat
+	}
+	return nil
+}
+
+func (x *Robot) GetPosition() *RobotPosition {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Robot) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *Robot) GetFaults() []string {
+	if x != nil {
+		return x.Faults
+	}
+	return nil
+}
+
+func (x *Robot) GetPositionAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PositionAt
+	}
+	return nil
+}
+
+type RobotTransition struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ReturnMsg     string                 `protobuf:"bytes,1,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteOrderResponse) Reset() {
-	*x = DeleteOrderResponse{}
+func (x *RobotTransition) Reset() {
+	*x = RobotTransition{}
 	mi := &file_proto_order_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteOrderResponse) String() string {
+func (x *RobotTransition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteOrderResponse) ProtoMessage() {}
+func (*RobotTransition) ProtoMessage() {}
 
-func (x *DeleteOrderResponse) ProtoReflect() protoreflect.Message {
+func (x *RobotTransition) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_order_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -367,52 +574,1637 @@ func (x *DeleteOrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteOrderResponse.ProtoReflect.Descriptor instead.
-func (*DeleteOrderResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use RobotTransition.ProtoReflect.Descriptor instead.
+func (*RobotTransition) Descriptor() ([]byte, []int) {
 	return file_proto_order_service_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *DeleteOrderResponse) GetReturnMsg() string {
+func (x *RobotTransition) GetFrom() string {
 	if x != nil {
-		return x.ReturnMsg
+		return x.From
 	}
 	return ""
 }
 
-var File_proto_order_service_proto protoreflect.FileDescriptor
+func (x *RobotTransition) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
 
-const file_proto_order_service_proto_rawDesc = "" +
-	"\n" +
-	"\x19proto/order_service.proto\x12\rorder_service\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9c\x02\n" +
-	"\x05Order\x12\x19\n" +
-	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
-	"\tvendor_id\x18\x03 \x01(\tR\bvendorId\x12.\n" +
-	"\x05items\x18\x04 \x03(\v2\x18.order_service.OrderItemR\x05items\x12\x16\n" +
-	"\x06status\x18\x05 \x01(\tR\x06status\x129\n" +
-	"\n" +
-	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12$\n" +
-	"\x0edropoff_loc_id\x18\a \x01(\tR\fdropoffLocId\x12\x19\n" +
-	"\brobot_id\x18\b \x01(\tR\arobotId\"s\n" +
-	"\tOrderItem\x12\x17\n" +
-	"\aitem_id\x18\x01 \x01(\x03R\x06itemId\x12\x1b\n" +
-	"\titem_name\x18\x02 \x01(\tR\bitemName\x12\x1a\n" +
-	"\bquantity\x18\x03 \x01(\x05R\bquantity\x12\x14\n" +
-	"\x05price\x18\x04 \x01(\x01R\x05price\"@\n" +
-	"\x12InsertOrderRequest\x12*\n" +
-	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\"@\n" +
-	"\x12DeleteOrderRequest\x12*\n" +
-	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\"`\n" +
-	"\x13InsertOrderResponse\x12*\n" +
-	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\x12\x1d\n" +
-	"\n" +
-	"return_msg\x18\x02 \x01(\tR\treturnMsg\"4\n" +
-	"\x13DeleteOrderResponse\x12\x1d\n" +
-	"\n" +
-	"return_msg\x18\x01 \x01(\tR\treturnMsg2\xba\x01\n" +
-	"\fOrderHandler\x12T\n" +
-	"\vInsertOrder\x12!.order_service.InsertOrderRequest\x1a\".order_service.InsertOrderResponse\x12T\n" +
-	"\vDeleteOrder\x12!.order_service.DeleteOrderRequest\x1a\".order_service.DeleteOrderResponseB\x16Z\x14/proto;order_serviceb\x06proto3"
+func (x *RobotTransition) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *RobotTransition) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// a route handed to a robot and what came of it
+type AssignmentAttempt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderIds      []int64                `protobuf:"varint,1,rep,packed,name=order_ids,json=orderIds,proto3" json:"order_ids,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Outcome       string                 `protobuf:"bytes,3,opt,name=outcome,proto3" json:"outcome,omitempty"` //accepted, rejected, timed_out or undelivered
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignmentAttempt) Reset() {
+	*x = AssignmentAttempt{}
+	mi := &file_proto_order_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignmentAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignmentAttempt) ProtoMessage() {}
+
+func (x *AssignmentAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignmentAttempt.ProtoReflect.Descriptor instead.
+func (*AssignmentAttempt) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AssignmentAttempt) GetOrderIds() []int64 {
+	if x != nil {
+		return x.OrderIds
+	}
+	return nil
+}
+
+func (x *AssignmentAttempt) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *AssignmentAttempt) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *AssignmentAttempt) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// narrows down a list of orders, unset fields match everything
+type OrderFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Statuses      []string               `protobuf:"bytes,1,rep,name=statuses,proto3" json:"statuses,omitempty"` //same names as Order.status
+	CreatedAfter  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`
+	CreatedBefore *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderFilter) Reset() {
+	*x = OrderFilter{}
+	mi := &file_proto_order_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderFilter) ProtoMessage() {}
+
+func (x *OrderFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderFilter.ProtoReflect.Descriptor instead.
+func (*OrderFilter) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OrderFilter) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *OrderFilter) GetCreatedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return nil
+}
+
+func (x *OrderFilter) GetCreatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return nil
+}
+
+// --------REQUESTS---------//
+type InsertOrderRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Order          *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` //or idempotency-key metadata, a retry with the same key gets the first order back instead of a new one
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InsertOrderRequest) Reset() {
+	*x = InsertOrderRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InsertOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InsertOrderRequest) ProtoMessage() {}
+
+func (x *InsertOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InsertOrderRequest.ProtoReflect.Descriptor instead.
+func (*InsertOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *InsertOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *InsertOrderRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type DeleteOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteOrderRequest) Reset() {
+	*x = DeleteOrderRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteOrderRequest) ProtoMessage() {}
+
+func (x *DeleteOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteOrderRequest.ProtoReflect.Descriptor instead.
+func (*DeleteOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetOrderRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+type ListOrdersByUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Filter        *OrderFilter           `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   //defaults to 20, at most 100
+	PageToken     string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` //next_page_token from the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersByUserRequest) Reset() {
+	*x = ListOrdersByUserRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersByUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersByUserRequest) ProtoMessage() {}
+
+func (x *ListOrdersByUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersByUserRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersByUserRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListOrdersByUserRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListOrdersByUserRequest) GetFilter() *OrderFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListOrdersByUserRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListOrdersByUserRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListOrdersByVendorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VendorId      string                 `protobuf:"bytes,1,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`
+	Filter        *OrderFilter           `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersByVendorRequest) Reset() {
+	*x = ListOrdersByVendorRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersByVendorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersByVendorRequest) ProtoMessage() {}
+
+func (x *ListOrdersByVendorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersByVendorRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersByVendorRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListOrdersByVendorRequest) GetVendorId() string {
+	if x != nil {
+		return x.VendorId
+	}
+	return ""
+}
+
+func (x *ListOrdersByVendorRequest) GetFilter() *OrderFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListOrdersByVendorRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListOrdersByVendorRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type UpdateOrderStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` //picked_up, in_transit, arrived, delivered, cancelled or failed, the rest are up to the matcher
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusRequest) Reset() {
+	*x = UpdateOrderStatusRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusRequest) ProtoMessage() {}
+
+func (x *UpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateOrderStatusRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *UpdateOrderStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type WatchOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrderRequest) Reset() {
+	*x = WatchOrderRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrderRequest) ProtoMessage() {}
+
+func (x *WatchOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrderRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchOrderRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+type ListRobotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRobotsRequest) Reset() {
+	*x = ListRobotsRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRobotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRobotsRequest) ProtoMessage() {}
+
+func (x *ListRobotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRobotsRequest.ProtoReflect.Descriptor instead.
+func (*ListRobotsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{15}
+}
+
+type GetRobotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RobotId       string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRobotRequest) Reset() {
+	*x = GetRobotRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRobotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRobotRequest) ProtoMessage() {}
+
+func (x *GetRobotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRobotRequest.ProtoReflect.Descriptor instead.
+func (*GetRobotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetRobotRequest) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+type DrainRobotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RobotId       string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	Resume        bool                   `protobuf:"varint,2,opt,name=resume,proto3" json:"resume,omitempty"` //takes the robot back out of draining
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainRobotRequest) Reset() {
+	*x = DrainRobotRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainRobotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainRobotRequest) ProtoMessage() {}
+
+func (x *DrainRobotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainRobotRequest.ProtoReflect.Descriptor instead.
+func (*DrainRobotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DrainRobotRequest) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+func (x *DrainRobotRequest) GetResume() bool {
+	if x != nil {
+		return x.Resume
+	}
+	return false
+}
+
+type RecallRobotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RobotId       string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` //passed on to the robot
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecallRobotRequest) Reset() {
+	*x = RecallRobotRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallRobotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallRobotRequest) ProtoMessage() {}
+
+func (x *RecallRobotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallRobotRequest.ProtoReflect.Descriptor instead.
+func (*RecallRobotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RecallRobotRequest) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+func (x *RecallRobotRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ForceUnassignRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceUnassignRequest) Reset() {
+	*x = ForceUnassignRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceUnassignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceUnassignRequest) ProtoMessage() {}
+
+func (x *ForceUnassignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceUnassignRequest.ProtoReflect.Descriptor instead.
+func (*ForceUnassignRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ForceUnassignRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *ForceUnassignRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RegisterRobotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RobotId       string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"` //left empty to have the database pick one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRobotRequest) Reset() {
+	*x = RegisterRobotRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRobotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRobotRequest) ProtoMessage() {}
+
+func (x *RegisterRobotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRobotRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRobotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RegisterRobotRequest) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+type IssueRobotTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RobotId       string                 `protobuf:"bytes,1,opt,name=robot_id,json=robotId,proto3" json:"robot_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssueRobotTokenRequest) Reset() {
+	*x = IssueRobotTokenRequest{}
+	mi := &file_proto_order_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueRobotTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueRobotTokenRequest) ProtoMessage() {}
+
+func (x *IssueRobotTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueRobotTokenRequest.ProtoReflect.Descriptor instead.
+func (*IssueRobotTokenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *IssueRobotTokenRequest) GetRobotId() string {
+	if x != nil {
+		return x.RobotId
+	}
+	return ""
+}
+
+// ---------RESPONSES----------
+type InsertOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	ReturnMsg     string                 `protobuf:"bytes,2,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InsertOrderResponse) Reset() {
+	*x = InsertOrderResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InsertOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InsertOrderResponse) ProtoMessage() {}
+
+func (x *InsertOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InsertOrderResponse.ProtoReflect.Descriptor instead.
+func (*InsertOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *InsertOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *InsertOrderResponse) GetReturnMsg() string {
+	if x != nil {
+		return x.ReturnMsg
+	}
+	return ""
+}
+
+type DeleteOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReturnMsg     string                 `protobuf:"bytes,1,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteOrderResponse) Reset() {
+	*x = DeleteOrderResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteOrderResponse) ProtoMessage() {}
+
+func (x *DeleteOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteOrderResponse.ProtoReflect.Descriptor instead.
+func (*DeleteOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteOrderResponse) GetReturnMsg() string {
+	if x != nil {
+		return x.ReturnMsg
+	}
+	return ""
+}
+
+type GetOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	EtaSeconds    int32                  `protobuf:"varint,2,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"` //until the robot carrying it reaches the drop-off, 0 until a robot has it and reports its position
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderResponse) Reset() {
+	*x = GetOrderResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderResponse) ProtoMessage() {}
+
+func (x *GetOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderResponse.ProtoReflect.Descriptor instead.
+func (*GetOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *GetOrderResponse) GetEtaSeconds() int32 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+type ListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`                                      //oldest first, without items, GetOrder has those
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` //empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ListOrdersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type UpdateOrderStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	ReturnMsg     string                 `protobuf:"bytes,2,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusResponse) Reset() {
+	*x = UpdateOrderStatusResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusResponse) ProtoMessage() {}
+
+func (x *UpdateOrderStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusResponse.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *UpdateOrderStatusResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *UpdateOrderStatusResponse) GetReturnMsg() string {
+	if x != nil {
+		return x.ReturnMsg
+	}
+	return ""
+}
+
+type ListRobotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robots        []*Robot               `protobuf:"bytes,1,rep,name=robots,proto3" json:"robots,omitempty"` //registered robots and any connected ones that aren't
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRobotsResponse) Reset() {
+	*x = ListRobotsResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRobotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRobotsResponse) ProtoMessage() {}
+
+func (x *ListRobotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRobotsResponse.ProtoReflect.Descriptor instead.
+func (*ListRobotsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListRobotsResponse) GetRobots() []*Robot {
+	if x != nil {
+		return x.Robots
+	}
+	return nil
+}
+
+type GetRobotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robot         *Robot                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	History       []*RobotTransition     `protobuf:"bytes,2,rep,name=history,proto3" json:"history,omitempty"`   //oldest first
+	Attempts      []*AssignmentAttempt   `protobuf:"bytes,3,rep,name=attempts,proto3" json:"attempts,omitempty"` //oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRobotResponse) Reset() {
+	*x = GetRobotResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRobotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRobotResponse) ProtoMessage() {}
+
+func (x *GetRobotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRobotResponse.ProtoReflect.Descriptor instead.
+func (*GetRobotResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetRobotResponse) GetRobot() *Robot {
+	if x != nil {
+		return x.Robot
+	}
+	return nil
+}
+
+func (x *GetRobotResponse) GetHistory() []*RobotTransition {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *GetRobotResponse) GetAttempts() []*AssignmentAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+type DrainRobotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robot         *Robot                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainRobotResponse) Reset() {
+	*x = DrainRobotResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainRobotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainRobotResponse) ProtoMessage() {}
+
+func (x *DrainRobotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainRobotResponse.ProtoReflect.Descriptor instead.
+func (*DrainRobotResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DrainRobotResponse) GetRobot() *Robot {
+	if x != nil {
+		return x.Robot
+	}
+	return nil
+}
+
+type RecallRobotResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RequeuedOrderIds []int64                `protobuf:"varint,1,rep,packed,name=requeued_order_ids,json=requeuedOrderIds,proto3" json:"requeued_order_ids,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RecallRobotResponse) Reset() {
+	*x = RecallRobotResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallRobotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallRobotResponse) ProtoMessage() {}
+
+func (x *RecallRobotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallRobotResponse.ProtoReflect.Descriptor instead.
+func (*RecallRobotResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *RecallRobotResponse) GetRequeuedOrderIds() []int64 {
+	if x != nil {
+		return x.RequeuedOrderIds
+	}
+	return nil
+}
+
+type ForceUnassignResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReturnMsg     string                 `protobuf:"bytes,1,opt,name=return_msg,json=returnMsg,proto3" json:"return_msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceUnassignResponse) Reset() {
+	*x = ForceUnassignResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceUnassignResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceUnassignResponse) ProtoMessage() {}
+
+func (x *ForceUnassignResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceUnassignResponse.ProtoReflect.Descriptor instead.
+func (*ForceUnassignResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ForceUnassignResponse) GetReturnMsg() string {
+	if x != nil {
+		return x.ReturnMsg
+	}
+	return ""
+}
+
+type RegisterRobotResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Robot          *Robot                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	Token          string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"` //the robot sends this as a bearer token when it connects to /ws
+	TokenExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=token_expires_at,json=tokenExpiresAt,proto3" json:"token_expires_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RegisterRobotResponse) Reset() {
+	*x = RegisterRobotResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRobotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRobotResponse) ProtoMessage() {}
+
+func (x *RegisterRobotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRobotResponse.ProtoReflect.Descriptor instead.
+func (*RegisterRobotResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RegisterRobotResponse) GetRobot() *Robot {
+	if x != nil {
+		return x.Robot
+	}
+	return nil
+}
+
+func (x *RegisterRobotResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *RegisterRobotResponse) GetTokenExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TokenExpiresAt
+	}
+	return nil
+}
+
+type IssueRobotTokenResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Token          string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	TokenExpiresAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=token_expires_at,json=tokenExpiresAt,proto3" json:"token_expires_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *IssueRobotTokenResponse) Reset() {
+	*x = IssueRobotTokenResponse{}
+	mi := &file_proto_order_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueRobotTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueRobotTokenResponse) ProtoMessage() {}
+
+func (x *IssueRobotTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueRobotTokenResponse.ProtoReflect.Descriptor instead.
+func (*IssueRobotTokenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *IssueRobotTokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *IssueRobotTokenResponse) GetTokenExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TokenExpiresAt
+	}
+	return nil
+}
+
+var File_proto_order_service_proto protoreflect.FileDescriptor
+
+const file_proto_order_service_proto_rawDesc = "" +
+	"\n" +
+	"\x19proto/order_service.proto\x12\rorder_service\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd6\x02\n" +
+	"\x05Order\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tvendor_id\x18\x03 \x01(\tR\bvendorId\x12.\n" +
+	"\x05items\x18\x04 \x03(\v2\x18.order_service.OrderItemR\x05items\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12$\n" +
+	"\x0edropoff_loc_id\x18\a \x01(\tR\fdropoffLocId\x12\x19\n" +
+	"\brobot_id\x18\b \x01(\tR\arobotId\x128\n" +
+	"\bpriority\x18\t \x01(\x0e2\x1c.order_service.OrderPriorityR\bpriority\"s\n" +
+	"\tOrderItem\x12\x17\n" +
+	"\aitem_id\x18\x01 \x01(\x03R\x06itemId\x12\x1b\n" +
+	"\titem_name\x18\x02 \x01(\tR\bitemName\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x05R\bquantity\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\"+\n" +
+	"\rRobotPosition\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\"\xdb\x01\n" +
+	"\vOrderUpdate\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x19\n" +
+	"\brobot_id\x18\x03 \x01(\tR\arobotId\x12C\n" +
+	"\x0erobot_position\x18\x04 \x01(\v2\x1c.order_service.RobotPositionR\rrobotPosition\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xf1\x04\n" +
+	"\x05Robot\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12;\n" +
+	"\vstate_since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"stateSince\x12\x1a\n" +
+	"\bdraining\x18\x04 \x01(\bR\bdraining\x12\x1e\n" +
+	"\n" +
+	"registered\x18\x05 \x01(\bR\n" +
+	"registered\x12$\n" +
+	"\x0ecurrent_loc_id\x18\x06 \x01(\tR\fcurrentLocId\x12;\n" +
+	"\vlast_update\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastUpdate\x12C\n" +
+	"\x0funhealthy_until\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x0eunhealthyUntil\x12\x14\n" +
+	"\x05stale\x18\t \x01(\bR\x05stale\x12\x18\n" +
+	"\abattery\x18\n" +
+	" \x01(\x05R\abattery\x12A\n" +
+	"\x0elast_heartbeat\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\rlastHeartbeat\x128\n" +
+	"\bposition\x18\f \x01(\v2\x1c.order_service.RobotPositionR\bposition\x12\x14\n" +
+	"\x05speed\x18\r \x01(\x01R\x05speed\x12\x16\n" +
+	"\x06faults\x18\x0e \x03(\tR\x06faults\x12;\n" +
+	"\vposition_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"positionAt\"y\n" +
+	"\x0fRobotTransition\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x8e\x01\n" +
+	"\x11AssignmentAttempt\x12\x1b\n" +
+	"\torder_ids\x18\x01 \x03(\x03R\borderIds\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\aoutcome\x18\x03 \x01(\tR\aoutcome\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xad\x01\n" +
+	"\vOrderFilter\x12\x1a\n" +
+	"\bstatuses\x18\x01 \x03(\tR\bstatuses\x12?\n" +
+	"\rcreated_after\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedAfter\x12A\n" +
+	"\x0ecreated_before\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\rcreatedBefore\"i\n" +
+	"\x12InsertOrderRequest\x12*\n" +
+	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\"@\n" +
+	"\x12DeleteOrderRequest\x12*\n" +
+	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\",\n" +
+	"\x0fGetOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\"\xa2\x01\n" +
+	"\x17ListOrdersByUserRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x122\n" +
+	"\x06filter\x18\x02 \x01(\v2\x1a.order_service.OrderFilterR\x06filter\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\xa8\x01\n" +
+	"\x19ListOrdersByVendorRequest\x12\x1b\n" +
+	"\tvendor_id\x18\x01 \x01(\tR\bvendorId\x122\n" +
+	"\x06filter\x18\x02 \x01(\v2\x1a.order_service.OrderFilterR\x06filter\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"M\n" +
+	"\x18UpdateOrderStatusRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\".\n" +
+	"\x11WatchOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\"\x13\n" +
+	"\x11ListRobotsRequest\",\n" +
+	"\x0fGetRobotRequest\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\"F\n" +
+	"\x11DrainRobotRequest\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\x12\x16\n" +
+	"\x06resume\x18\x02 \x01(\bR\x06resume\"G\n" +
+	"\x12RecallRobotRequest\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"I\n" +
+	"\x14ForceUnassignRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"1\n" +
+	"\x14RegisterRobotRequest\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\"3\n" +
+	"\x16IssueRobotTokenRequest\x12\x19\n" +
+	"\brobot_id\x18\x01 \x01(\tR\arobotId\"`\n" +
+	"\x13InsertOrderResponse\x12*\n" +
+	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\x12\x1d\n" +
+	"\n" +
+	"return_msg\x18\x02 \x01(\tR\treturnMsg\"4\n" +
+	"\x13DeleteOrderResponse\x12\x1d\n" +
+	"\n" +
+	"return_msg\x18\x01 \x01(\tR\treturnMsg\"_\n" +
+	"\x10GetOrderResponse\x12*\n" +
+	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\x12\x1f\n" +
+	"\veta_seconds\x18\x02 \x01(\x05R\n" +
+	"etaSeconds\"j\n" +
+	"\x12ListOrdersResponse\x12,\n" +
+	"\x06orders\x18\x01 \x03(\v2\x14.order_service.OrderR\x06orders\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"f\n" +
+	"\x19UpdateOrderStatusResponse\x12*\n" +
+	"\x05order\x18\x01 \x01(\v2\x14.order_service.OrderR\x05order\x12\x1d\n" +
+	"\n" +
+	"return_msg\x18\x02 \x01(\tR\treturnMsg\"B\n" +
+	"\x12ListRobotsResponse\x12,\n" +
+	"\x06robots\x18\x01 \x03(\v2\x14.order_service.RobotR\x06robots\"\xb6\x01\n" +
+	"\x10GetRobotResponse\x12*\n" +
+	"\x05robot\x18\x01 \x01(\v2\x14.order_service.RobotR\x05robot\x128\n" +
+	"\ahistory\x18\x02 \x03(\v2\x1e.order_service.RobotTransitionR\ahistory\x12<\n" +
+	"\battempts\x18\x03 \x03(\v2 .order_service.AssignmentAttemptR\battempts\"@\n" +
+	"\x12DrainRobotResponse\x12*\n" +
+	"\x05robot\x18\x01 \x01(\v2\x14.order_service.RobotR\x05robot\"C\n" +
+	"\x13RecallRobotResponse\x12,\n" +
+	"\x12requeued_order_ids\x18\x01 \x03(\x03R\x10requeuedOrderIds\"6\n" +
+	"\x15ForceUnassignResponse\x12\x1d\n" +
+	"\n" +
+	"return_msg\x18\x01 \x01(\tR\treturnMsg\"\x9f\x01\n" +
+	"\x15RegisterRobotResponse\x12*\n" +
+	"\x05robot\x18\x01 \x01(\v2\x14.order_service.RobotR\x05robot\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12D\n" +
+	"\x10token_expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0etokenExpiresAt\"u\n" +
+	"\x17IssueRobotTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12D\n" +
+	"\x10token_expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x0etokenExpiresAt*\x80\x01\n" +
+	"\rOrderPriority\x12\x1b\n" +
+	"\x17ORDER_PRIORITY_STANDARD\x10\x00\x12\x1a\n" +
+	"\x16ORDER_PRIORITY_EXPRESS\x10\x01\x12\x18\n" +
+	"\x14ORDER_PRIORITY_STAFF\x10\x02\x12\x1c\n" +
+	"\x18ORDER_PRIORITY_SCHEDULED\x10\x032\xff\x04\n" +
+	"\fOrderHandler\x12T\n" +
+	"\vInsertOrder\x12!.order_service.InsertOrderRequest\x1a\".order_service.InsertOrderResponse\x12T\n" +
+	"\vDeleteOrder\x12!.order_service.DeleteOrderRequest\x1a\".order_service.DeleteOrderResponse\x12K\n" +
+	"\bGetOrder\x12\x1e.order_service.GetOrderRequest\x1a\x1f.order_service.GetOrderResponse\x12]\n" +
+	"\x10ListOrdersByUser\x12&.order_service.ListOrdersByUserRequest\x1a!.order_service.ListOrdersResponse\x12a\n" +
+	"\x12ListOrdersByVendor\x12(.order_service.ListOrdersByVendorRequest\x1a!.order_service.ListOrdersResponse\x12f\n" +
+	"\x11UpdateOrderStatus\x12'.order_service.UpdateOrderStatusRequest\x1a(.order_service.UpdateOrderStatusResponse\x12L\n" +
+	"\n" +
+	"WatchOrder\x12 .order_service.WatchOrderRequest\x1a\x1a.order_service.OrderUpdate0\x012\xef\x04\n" +
+	"\n" +
+	"FleetAdmin\x12Q\n" +
+	"\n" +
+	"ListRobots\x12 .order_service.ListRobotsRequest\x1a!.order_service.ListRobotsResponse\x12K\n" +
+	"\bGetRobot\x12\x1e.order_service.GetRobotRequest\x1a\x1f.order_service.GetRobotResponse\x12Q\n" +
+	"\n" +
+	"DrainRobot\x12 .order_service.DrainRobotRequest\x1a!.order_service.DrainRobotResponse\x12T\n" +
+	"\vRecallRobot\x12!.order_service.RecallRobotRequest\x1a\".order_service.RecallRobotResponse\x12Z\n" +
+	"\rForceUnassign\x12#.order_service.ForceUnassignRequest\x1a$.order_service.ForceUnassignResponse\x12Z\n" +
+	"\rRegisterRobot\x12#.order_service.RegisterRobotRequest\x1a$.order_service.RegisterRobotResponse\x12`\n" +
+	"\x0fIssueRobotToken\x12%.order_service.IssueRobotTokenRequest\x1a&.order_service.IssueRobotTokenResponseB\x16Z\x14/proto;order_serviceb\x06proto3"
 
 var (
 	file_proto_order_service_proto_rawDescOnce sync.Once
@@ -426,31 +2218,111 @@ func file_proto_order_service_proto_rawDescGZIP() []byte {
 	return file_proto_order_service_proto_rawDescData
 }
 
-var file_proto_order_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_order_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_order_service_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_proto_order_service_proto_goTypes = []any{
-	(*Order)(nil),                 // 0: order_service.Order
-	(*OrderItem)(nil),             // 1: order_service.OrderItem
-	(*InsertOrderRequest)(nil),    // 2: order_service.InsertOrderRequest
-	(*DeleteOrderRequest)(nil),    // 3: order_service.DeleteOrderRequest
-	(*InsertOrderResponse)(nil),   // 4: order_service.InsertOrderResponse
-	(*DeleteOrderResponse)(nil),   // 5: order_service.DeleteOrderResponse
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(OrderPriority)(0),                // 0: order_service.OrderPriority
+	(*Order)(nil),                     // 1: order_service.Order
+	(*OrderItem)(nil),                 // 2: order_service.OrderItem
+	(*RobotPosition)(nil),             // 3: order_service.RobotPosition
+	(*OrderUpdate)(nil),               // 4: order_service.OrderUpdate
+	(*Robot)(nil),                     // 5: order_service.Robot
+	(*RobotTransition)(nil),           // 6: order_service.RobotTransition
+	(*AssignmentAttempt)(nil),         // 7: order_service.AssignmentAttempt
+	(*OrderFilter)(nil),               // 8: order_service.OrderFilter
+	(*InsertOrderRequest)(nil),        // 9: order_service.InsertOrderRequest
+	(*DeleteOrderRequest)(nil),        // 10: order_service.DeleteOrderRequest
+	(*GetOrderRequest)(nil),           // 11: order_service.GetOrderRequest
+	(*ListOrdersByUserRequest)(nil),   // 12: order_service.ListOrdersByUserRequest
+	(*ListOrdersByVendorRequest)(nil), // 13: order_service.ListOrdersByVendorRequest
+	(*UpdateOrderStatusRequest)(nil),  // 14: order_service.UpdateOrderStatusRequest
+	(*WatchOrderRequest)(nil),         // 15: order_service.WatchOrderRequest
+	(*ListRobotsRequest)(nil),         // 16: order_service.ListRobotsRequest
+	(*GetRobotRequest)(nil),           // 17: order_service.GetRobotRequest
+	(*DrainRobotRequest)(nil),         // 18: order_service.DrainRobotRequest
+	(*RecallRobotRequest)(nil),        // 19: order_service.RecallRobotRequest
+	(*ForceUnassignRequest)(nil),      // 20: order_service.ForceUnassignRequest
+	(*RegisterRobotRequest)(nil),      // 21: order_service.RegisterRobotRequest
+	(*IssueRobotTokenRequest)(nil),    // 22: order_service.IssueRobotTokenRequest
+	(*InsertOrderResponse)(nil),       // 23: order_service.InsertOrderResponse
+	(*DeleteOrderResponse)(nil),       // 24: order_service.DeleteOrderResponse
+	(*GetOrderResponse)(nil),          // 25: order_service.GetOrderResponse
+	(*ListOrdersResponse)(nil),        // 26: order_service.ListOrdersResponse
+	(*UpdateOrderStatusResponse)(nil), // 27: order_service.UpdateOrderStatusResponse
+	(*ListRobotsResponse)(nil),        // 28: order_service.ListRobotsResponse
+	(*GetRobotResponse)(nil),          // 29: order_service.GetRobotResponse
+	(*DrainRobotResponse)(nil),        // 30: order_service.DrainRobotResponse
+	(*RecallRobotResponse)(nil),       // 31: order_service.RecallRobotResponse
+	(*ForceUnassignResponse)(nil),     // 32: order_service.ForceUnassignResponse
+	(*RegisterRobotResponse)(nil),     // 33: order_service.RegisterRobotResponse
+	(*IssueRobotTokenResponse)(nil),   // 34: order_service.IssueRobotTokenResponse
+	(*timestamppb.Timestamp)(nil),     // 35: google.protobuf.Timestamp
 }
 var file_proto_order_service_proto_depIdxs = []int32{
-	1, // 0: order_service.Order.items:type_name -> order_service.OrderItem
-	6, // 1: order_service.Order.created_at:type_name -> google.protobuf.Timestamp
-	0, // 2: order_service.InsertOrderRequest.order:type_name -> order_service.Order
-	0, // 3: order_service.DeleteOrderRequest.order:type_name -> order_service.Order
-	0, // 4: order_service.InsertOrderResponse.order:type_name -> order_service.Order
-	2, // 5: order_service.OrderHandler.InsertOrder:input_type -> order_service.InsertOrderRequest
-	3, // 6: order_service.OrderHandler.DeleteOrder:input_type -> order_service.DeleteOrderRequest
-	4, // 7: order_service.OrderHandler.InsertOrder:output_type -> order_service.InsertOrderResponse
-	5, // 8: order_service.OrderHandler.DeleteOrder:output_type -> order_service.DeleteOrderResponse
-	7, // [7:9] is the sub-list for method output_type
-	5, // [5:7] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	2,  // 0: order_service.Order.items:type_name -> order_service.OrderItem
+	35, // 1: order_service.Order.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: order_service.Order.priority:type_name -> order_service.OrderPriority
+	3,  // 3: order_service.OrderUpdate.robot_position:type_name -> order_service.RobotPosition
+	35, // 4: order_service.OrderUpdate.updated_at:type_name -> google.protobuf.Timestamp
+	35, // 5: order_service.Robot.state_since:type_name -> google.protobuf.Timestamp
+	35, // 6: order_service.Robot.last_update:type_name -> google.protobuf.Timestamp
+	35, // 7: order_service.Robot.unhealthy_until:type_name -> google.protobuf.Timestamp
+	35, // 8: order_service.Robot.last_heartbeat:type_name -> google.protobuf.Timestamp
+	3,  // 9: order_service.Robot.position:type_name -> order_service.RobotPosition
+	35, // 10: order_service.Robot.position_at:type_name -> google.protobuf.Timestamp
+	35, // 11: order_service.RobotTransition.at:type_name -> google.protobuf.Timestamp
+	35, // 12: order_service.AssignmentAttempt.at:type_name -> google.protobuf.Timestamp
+	35, // 13: order_service.OrderFilter.created_after:type_name -> google.protobuf.Timestamp
+	35, // 14: order_service.OrderFilter.created_before:type_name -> google.protobuf.Timestamp
+	1,  // 15: order_service.InsertOrderRequest.order:type_name -> order_service.Order
+	1,  // 16: order_service.DeleteOrderRequest.order:type_name -> order_service.Order
+	8,  // 17: order_service.ListOrdersByUserRequest.filter:type_name -> order_service.OrderFilter
+	8,  // 18: order_service.ListOrdersByVendorRequest.filter:type_name -> order_service.OrderFilter
+	1,  // 19: order_service.InsertOrderResponse.order:type_name -> order_service.Order
+	1,  // 20: order_service.GetOrderResponse.order:type_name -> order_service.Order
+	1,  // 21: order_service.ListOrdersResponse.orders:type_name -> order_service.Order
+	1,  // 22: order_service.UpdateOrderStatusResponse.order:type_name -> order_service.Order
+	5,  // 23: order_service.ListRobotsResponse.robots:type_name -> order_service.Robot
+	5,  // 24: order_service.GetRobotResponse.robot:type_name -> order_service.Robot
+	6,  // 25: order_service.GetRobotResponse.history:type_name -> order_service.RobotTransition
+	7,  // 26: order_service.GetRobotResponse.attempts:type_name -> order_service.AssignmentAttempt
+	5,  // 27: order_service.DrainRobotResponse.robot:type_name -> order_service.Robot
+	5,  // 28: order_service.RegisterRobotResponse.robot:type_name -> order_service.Robot
+	35, // 29: order_service.RegisterRobotResponse.token_expires_at:type_name -> google.protobuf.Timestamp
+	35, // 30: order_service.IssueRobotTokenResponse.token_expires_at:type_name -> google.protobuf.Timestamp
+	9,  // 31: order_service.OrderHandler.InsertOrder:input_type -> order_service.InsertOrderRequest
+	10, // 32: order_service.OrderHandler.DeleteOrder:input_type -> order_service.DeleteOrderRequest
+	11, // 33: order_service.OrderHandler.GetOrder:input_type -> order_service.GetOrderRequest
+	12, // 34: order_service.OrderHandler.ListOrdersByUser:input_type -> order_service.ListOrdersByUserRequest
+	13, // 35: order_service.OrderHandler.ListOrdersByVendor:input_type -> order_service.ListOrdersByVendorRequest
+	14, // 36: order_service.OrderHandler.UpdateOrderStatus:input_type -> order_service.UpdateOrderStatusRequest
+	15, // 37: order_service.OrderHandler.WatchOrder:input_type -> order_service.WatchOrderRequest
+	16, // 38: order_service.FleetAdmin.ListRobots:input_type -> order_service.ListRobotsRequest
+	17, // 39: order_service.FleetAdmin.GetRobot:input_type -> order_service.GetRobotRequest
+	18, // 40: order_service.FleetAdmin.DrainRobot:input_type -> order_service.DrainRobotRequest
+	19, // 41: order_service.FleetAdmin.RecallRobot:input_type -> order_service.RecallRobotRequest
+	20, // 42: order_service.FleetAdmin.ForceUnassign:input_type -> order_service.ForceUnassignRequest
+	21, // 43: order_service.FleetAdmin.RegisterRobot:input_type -> order_service.RegisterRobotRequest
+	22, // 44: order_service.FleetAdmin.IssueRobotToken:input_type -> order_service.IssueRobotTokenRequest
+	23, // 45: order_service.OrderHandler.InsertOrder:output_type -> order_service.InsertOrderResponse
+	24, // 46: order_service.OrderHandler.DeleteOrder:output_type -> order_service.DeleteOrderResponse
+	25, // 47: order_service.OrderHandler.GetOrder:output_type -> order_service.GetOrderResponse
+	26, // 48: order_service.OrderHandler.ListOrdersByUser:output_type -> order_service.ListOrdersResponse
+	26, // 49: order_service.OrderHandler.ListOrdersByVendor:output_type -> order_service.ListOrdersResponse
+	27, // 50: order_service.OrderHandler.UpdateOrderStatus:output_type -> order_service.UpdateOrderStatusResponse
+	4,  // 51: order_service.OrderHandler.WatchOrder:output_type -> order_service.OrderUpdate
+	28, // 52: order_service.FleetAdmin.ListRobots:output_type -> order_service.ListRobotsResponse
+	29, // 53: order_service.FleetAdmin.GetRobot:output_type -> order_service.GetRobotResponse
+	30, // 54: order_service.FleetAdmin.DrainRobot:output_type -> order_service.DrainRobotResponse
+	31, // 55: order_service.FleetAdmin.RecallRobot:output_type -> order_service.RecallRobotResponse
+	32, // 56: order_service.FleetAdmin.ForceUnassign:output_type -> order_service.ForceUnassignResponse
+	33, // 57: order_service.FleetAdmin.RegisterRobot:output_type -> order_service.RegisterRobotResponse
+	34, // 58: order_service.FleetAdmin.IssueRobotToken:output_type -> order_service.IssueRobotTokenResponse
+	45, // [45:59] is the sub-list for method output_type
+	31, // [31:45] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_proto_order_service_proto_init() }
@@ -463,13 +2335,14 @@ func file_proto_order_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_order_service_proto_rawDesc), len(file_proto_order_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   34,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_proto_order_service_proto_goTypes,
 		DependencyIndexes: file_proto_order_service_proto_depIdxs,
+		EnumInfos:         file_proto_order_service_proto_enumTypes,
 		MessageInfos:      file_proto_order_service_proto_msgTypes,
 	}.Build()
 	File_proto_order_service_proto = out.File
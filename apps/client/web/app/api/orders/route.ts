@@ -1,7 +1,7 @@
 import { NextRequest, NextResponse } from 'next/server';
 import { createClient } from '@supabase/supabase-js';
 import { jwtVerify } from 'jose';
-import { getOrderClient } from '@/lib/grpc-client';
+import { getOrderClient, userMetadata } from '@/lib/grpc-client';
 import { promisify } from 'util';
 
 const supabase = createClient(
@@ -9,24 +9,39 @@ const supabase = createClient(
   process.env.SUPABASE_SERVICE_ROLE_KEY!
 );
 
-const JWT_SECRET = new TextEncoder().encode(
-  process.env.JWT_SECRET || 'your-secret-key-change-this'
-);
+// the supabase project's jwt secret, the session token is forwarded to the authoritative server which checks it with the same secret
+const JWT_SECRET = new TextEncoder().encode(process.env.JWT_SECRET!);
 export async function POST(request: NextRequest) {
   try {
-    // Get user from JWT token
+    // only signed in users can order, their token goes along with the grpc call
     const token = request.cookies.get('auth-token')?.value;
-    let userId = null;
+    let userId: string | null = null;
 
     if (token) {
       try {
         const { payload } = await jwtVerify(token, JWT_SECRET);
-        userId = payload.userId as string;
+        userId = payload.sub ?? null;
       } catch (error) {
         console.error('JWT verification failed:', error);
       }
     }
 
+    if (!token || !userId) {
+      return NextResponse.json(
+        { message: 'Sign in to place an order' },
+        { status: 401 }
+      );
+    }
+
+    // the checkout picks one key and sends it on every retry, the same key gets the first order back instead of placing it twice
+    const idempotencyKey = request.headers.get('idempotency-key');
+    if (!idempotencyKey) {
+      return NextResponse.json(
+        { message: 'Idempotency-Key header is required' },
+        { status: 400 }
+      );
+    }
+
     const orderData = await request.json();
     console.log('Received order data:', orderData);
     // Validate required fields
@@ -74,9 +89,6 @@ export async function POST(request: NextRequest) {
       );
     }
 
-    // Get actual user_id from JWT token or use placeholder
-    const finalUserId = userId || "guest-user";
-
     // Prepare order items for protobuf format
     // eslint-disable-next-line @typescript-eslint/no-explicit-any
     const protoItems = orderData.items.map((item: any) => ({
@@ -89,7 +101,7 @@ export async function POST(request: NextRequest) {
     // Create the gRPC order object matching your protobuf structure
     const grpcOrder = {
       order_id: 0, // Will be assigned by the gRPC server
-      user_id: finalUserId,
+      user_id: userId,
       vendor_id: vendorData.id, // Use the vendor_id from the vendors table
       items: protoItems,
       status: 'pending',
@@ -101,12 +113,13 @@ export async function POST(request: NextRequest) {
       robot_id:null, // Empty string for null, will be assigned by matching system
     };
 
+
     // Make gRPC call to InsertOrder
     const client = getOrderClient();
     const insertOrder = promisify(client.InsertOrder.bind(client));
 
     try {
-      const response = await insertOrder({ order: grpcOrder });
+      const response = await insertOrder({ order: grpcOrder, idempotency_key: idempotencyKey }, userMetadata(token));
       
       return NextResponse.json(
         {
@@ -45,6 +45,9 @@ export async function POST(request: NextRequest) {
     // Generate JWT token
     const token = jwt.sign(
       { 
+        // sub and role make this a supabase user token, the authoritative server takes it as is
+        sub: String(user.id),
+        role: 'authenticated',
         userId: user.id, 
         email: user.email,
         name: user.name 
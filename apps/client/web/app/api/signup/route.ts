@@ -66,6 +66,9 @@ export async function POST(request: NextRequest) {
     // Generate JWT token
     const token = jwt.sign(
       { 
+        // sub and role make this a supabase user token, the authoritative server takes it as is
+        sub: String(newUser.id),
+        role: 'authenticated',
         userId: newUser.id, 
         email: newUser.email,
         name: newUser.name 
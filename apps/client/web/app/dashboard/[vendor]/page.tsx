@@ -49,6 +49,10 @@ export default function VendorOrderPage() {
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState('')
   const [success, setSuccess] = useState('')
+  // one key per checkout, every retry sends the same one so an order whose response got lost isn't placed twice
+  // changing the cart or the drop-off starts a new checkout
+  const [idempotencyKey, setIdempotencyKey] = useState(() => crypto.randomUUID())
+  const newCheckout = () => setIdempotencyKey(crypto.randomUUID())
 
   // Dropoff location options
   const dropoffLocations = [
@@ -59,6 +63,7 @@ export default function VendorOrderPage() {
 
   // Add item to cart or increase quantity
   const addToCart = (menuItem: MenuItem) => {
+    newCheckout()
     setCart(prevCart => {
       const existingItem = prevCart.find(item => item.item_id === menuItem.item_id)
       
@@ -81,6 +86,7 @@ export default function VendorOrderPage() {
 
   // Remove item from cart
   const removeFromCart = (itemId: number) => {
+    newCheckout()
     setCart(prevCart => prevCart.filter(item => item.item_id !== itemId))
   }
 
@@ -91,6 +97,7 @@ export default function VendorOrderPage() {
       return
     }
     
+    newCheckout()
     setCart(prevCart =>
       prevCart.map(item =>
         item.item_id === itemId
@@ -138,6 +145,7 @@ export default function VendorOrderPage() {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
+          'Idempotency-Key': idempotencyKey,
         },
         body: JSON.stringify(orderData),
       })
@@ -154,6 +162,7 @@ export default function VendorOrderPage() {
       setTimeout(() => {
         setCart([])
         setDropoffLocation('')
+        newCheckout()
         router.push(`/order/${data.order_id}`) // Redirect back to dashboard
       }, 2000)
 
@@ -287,7 +296,10 @@ export default function VendorOrderPage() {
                 <select
                   id="dropoff"
                   value={dropoffLocation}
-                  onChange={(e) => setDropoffLocation(e.target.value)}
+                  onChange={(e) => {
+                    newCheckout()
+                    setDropoffLocation(e.target.value)
+                  }}
                   className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-purple-500 focus:border-transparent bg-white"
                 >
                   {dropoffLocations.map((location) => (
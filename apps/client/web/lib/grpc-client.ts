@@ -25,4 +25,12 @@ export const getOrderClient = () => {
     GRPC_SERVER_URL,
     grpc.credentials.createInsecure() // Use createSsl() for production with certificates
   );
-};
\ No newline at end of file
+};
+
+// the authoritative server wants a supabase jwt on every call, calls made for a user carry that user's own token
+// so the server checks who they are and what they own, never a user id the caller picked
+export const userMetadata = (accessToken: string) => {
+  const metadata = new grpc.Metadata();
+  metadata.set('authorization', `Bearer ${accessToken}`);
+  return metadata;
+};
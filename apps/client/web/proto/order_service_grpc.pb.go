@@ -1,4 +1,4 @@
-// protoc --go_out=. --go-grpc_out=. proto/order_service.proto
+// apps/authoritative: protoc --go_out=. --go-grpc_out=. proto/order_service.proto
 
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
@@ -21,18 +21,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	OrderHandler_InsertOrder_FullMethodName = "/order_service.OrderHandler/InsertOrder"
-	OrderHandler_DeleteOrder_FullMethodName = "/order_service.OrderHandler/DeleteOrder"
+	OrderHandler_InsertOrder_FullMethodName        = "/order_service.OrderHandler/InsertOrder"
+	OrderHandler_DeleteOrder_FullMethodName        = "/order_service.OrderHandler/DeleteOrder"
+	OrderHandler_GetOrder_FullMethodName           = "/order_service.OrderHandler/GetOrder"
+	OrderHandler_ListOrdersByUser_FullMethodName   = "/order_service.OrderHandler/ListOrdersByUser"
+	OrderHandler_ListOrdersByVendor_FullMethodName = "/order_service.OrderHandler/ListOrdersByVendor"
+	OrderHandler_UpdateOrderStatus_FullMethodName  = "/order_service.OrderHandler/UpdateOrderStatus"
+	OrderHandler_WatchOrder_FullMethodName         = "/order_service.OrderHandler/WatchOrder"
 )
 
 // OrderHandlerClient is the client API for OrderHandler service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// ----------SERVICE-----
+// ----------SERVICE--------//
 type OrderHandlerClient interface {
 	InsertOrder(ctx context.Context, in *InsertOrderRequest, opts ...grpc.CallOption) (*InsertOrderResponse, error)
 	DeleteOrder(ctx context.Context, in *DeleteOrderRequest, opts ...grpc.CallOption) (*DeleteOrderResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error)
+	ListOrdersByUser(ctx context.Context, in *ListOrdersByUserRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	ListOrdersByVendor(ctx context.Context, in *ListOrdersByVendorRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusRequest, opts ...grpc.CallOption) (*UpdateOrderStatusResponse, error)
+	WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderUpdate], error)
 }
 
 type orderHandlerClient struct {
@@ -63,14 +73,78 @@ func (c *orderHandlerClient) DeleteOrder(ctx context.Context, in *DeleteOrderReq
 	return out, nil
 }
 
+func (c *orderHandlerClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderResponse)
+	err := c.cc.Invoke(ctx, OrderHandler_GetOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderHandlerClient) ListOrdersByUser(ctx context.Context, in *ListOrdersByUserRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderHandler_ListOrdersByUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderHandlerClient) ListOrdersByVendor(ctx context.Context, in *ListOrdersByVendorRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderHandler_ListOrdersByVendor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderHandlerClient) UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusRequest, opts ...grpc.CallOption) (*UpdateOrderStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateOrderStatusResponse)
+	err := c.cc.Invoke(ctx, OrderHandler_UpdateOrderStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderHandlerClient) WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderHandler_ServiceDesc.Streams[0], OrderHandler_WatchOrder_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrderRequest, OrderUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderHandler_WatchOrderClient = grpc.ServerStreamingClient[OrderUpdate]
+
 // OrderHandlerServer is the server API for OrderHandler service.
 // All implementations must embed UnimplementedOrderHandlerServer
 // for forward compatibility.
 //
-// ----------SERVICE-----
+// ----------SERVICE--------//
 type OrderHandlerServer interface {
 	InsertOrder(context.Context, *InsertOrderRequest) (*InsertOrderResponse, error)
 	DeleteOrder(context.Context, *DeleteOrderRequest) (*DeleteOrderResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error)
+	ListOrdersByUser(context.Context, *ListOrdersByUserRequest) (*ListOrdersResponse, error)
+	ListOrdersByVendor(context.Context, *ListOrdersByVendorRequest) (*ListOrdersResponse, error)
+	UpdateOrderStatus(context.Context, *UpdateOrderStatusRequest) (*UpdateOrderStatusResponse, error)
+	WatchOrder(*WatchOrderRequest, grpc.ServerStreamingServer[OrderUpdate]) error
 	mustEmbedUnimplementedOrderHandlerServer()
 }
 
@@ -87,6 +161,21 @@ func (UnimplementedOrderHandlerServer) InsertOrder(context.Context, *InsertOrder
 func (UnimplementedOrderHandlerServer) DeleteOrder(context.Context, *DeleteOrderRequest) (*DeleteOrderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrder not implemented")
 }
+func (UnimplementedOrderHandlerServer) GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedOrderHandlerServer) ListOrdersByUser(context.Context, *ListOrdersByUserRequest) (*ListOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrdersByUser not implemented")
+}
+func (UnimplementedOrderHandlerServer) ListOrdersByVendor(context.Context, *ListOrdersByVendorRequest) (*ListOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrdersByVendor not implemented")
+}
+func (UnimplementedOrderHandlerServer) UpdateOrderStatus(context.Context, *UpdateOrderStatusRequest) (*UpdateOrderStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrderStatus not implemented")
+}
+func (UnimplementedOrderHandlerServer) WatchOrder(*WatchOrderRequest, grpc.ServerStreamingServer[OrderUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrder not implemented")
+}
 func (UnimplementedOrderHandlerServer) mustEmbedUnimplementedOrderHandlerServer() {}
 func (UnimplementedOrderHandlerServer) testEmbeddedByValue()                      {}
 
@@ -144,6 +233,89 @@ func _OrderHandler_DeleteOrder_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderHandler_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderHandlerServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderHandler_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderHandlerServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderHandler_ListOrdersByUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersByUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderHandlerServer).ListOrdersByUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderHandler_ListOrdersByUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderHandlerServer).ListOrdersByUser(ctx, req.(*ListOrdersByUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderHandler_ListOrdersByVendor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersByVendorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderHandlerServer).ListOrdersByVendor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderHandler_ListOrdersByVendor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderHandlerServer).ListOrdersByVendor(ctx, req.(*ListOrdersByVendorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderHandler_UpdateOrderStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrderStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderHandlerServer).UpdateOrderStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderHandler_UpdateOrderStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderHandlerServer).UpdateOrderStatus(ctx, req.(*UpdateOrderStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderHandler_WatchOrder_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderHandlerServer).WatchOrder(m, &grpc.GenericServerStream[WatchOrderRequest, OrderUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderHandler_WatchOrderServer = grpc.ServerStreamingServer[OrderUpdate]
+
 // OrderHandler_ServiceDesc is the grpc.ServiceDesc for OrderHandler service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -159,6 +331,362 @@ var OrderHandler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteOrder",
 			Handler:    _OrderHandler_DeleteOrder_Handler,
 		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _OrderHandler_GetOrder_Handler,
+		},
+		{
+			MethodName: "ListOrdersByUser",
+			Handler:    _OrderHandler_ListOrdersByUser_Handler,
+		},
+		{
+			MethodName: "ListOrdersByVendor",
+			Handler:    _OrderHandler_ListOrdersByVendor_Handler,
+		},
+		{
+			MethodName: "UpdateOrderStatus",
+			Handler:    _OrderHandler_UpdateOrderStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrder",
+			Handler:       _OrderHandler_WatchOrder_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/order_service.proto",
+}
+
+const (
+	FleetAdmin_ListRobots_FullMethodName      = "/order_service.FleetAdmin/ListRobots"
+	FleetAdmin_GetRobot_FullMethodName        = "/order_service.FleetAdmin/GetRobot"
+	FleetAdmin_DrainRobot_FullMethodName      = "/order_service.FleetAdmin/DrainRobot"
+	FleetAdmin_RecallRobot_FullMethodName     = "/order_service.FleetAdmin/RecallRobot"
+	FleetAdmin_ForceUnassign_FullMethodName   = "/order_service.FleetAdmin/ForceUnassign"
+	FleetAdmin_RegisterRobot_FullMethodName   = "/order_service.FleetAdmin/RegisterRobot"
+	FleetAdmin_IssueRobotToken_FullMethodName = "/order_service.FleetAdmin/IssueRobotToken"
+)
+
+// FleetAdminClient is the client API for FleetAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// for the ops dashboard, so it never has to read the robots table itself
+type FleetAdminClient interface {
+	ListRobots(ctx context.Context, in *ListRobotsRequest, opts ...grpc.CallOption) (*ListRobotsResponse, error)
+	GetRobot(ctx context.Context, in *GetRobotRequest, opts ...grpc.CallOption) (*GetRobotResponse, error)
+	DrainRobot(ctx context.Context, in *DrainRobotRequest, opts ...grpc.CallOption) (*DrainRobotResponse, error)
+	RecallRobot(ctx context.Context, in *RecallRobotRequest, opts ...grpc.CallOption) (*RecallRobotResponse, error)
+	ForceUnassign(ctx context.Context, in *ForceUnassignRequest, opts ...grpc.CallOption) (*ForceUnassignResponse, error)
+	RegisterRobot(ctx context.Context, in *RegisterRobotRequest, opts ...grpc.CallOption) (*RegisterRobotResponse, error)
+	IssueRobotToken(ctx context.Context, in *IssueRobotTokenRequest, opts ...grpc.CallOption) (*IssueRobotTokenResponse, error)
+}
+
+type fleetAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFleetAdminClient(cc grpc.ClientConnInterface) FleetAdminClient {
+	return &fleetAdminClient{cc}
+}
+
+func (c *fleetAdminClient) ListRobots(ctx context.Context, in *ListRobotsRequest, opts ...grpc.CallOption) (*ListRobotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRobotsResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_ListRobots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) GetRobot(ctx context.Context, in *GetRobotRequest, opts ...grpc.CallOption) (*GetRobotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRobotResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_GetRobot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) DrainRobot(ctx context.Context, in *DrainRobotRequest, opts ...grpc.CallOption) (*DrainRobotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainRobotResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_DrainRobot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) RecallRobot(ctx context.Context, in *RecallRobotRequest, opts ...grpc.CallOption) (*RecallRobotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecallRobotResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_RecallRobot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) ForceUnassign(ctx context.Context, in *ForceUnassignRequest, opts ...grpc.CallOption) (*ForceUnassignResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForceUnassignResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_ForceUnassign_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) RegisterRobot(ctx context.Context, in *RegisterRobotRequest, opts ...grpc.CallOption) (*RegisterRobotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterRobotResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_RegisterRobot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetAdminClient) IssueRobotToken(ctx context.Context, in *IssueRobotTokenRequest, opts ...grpc.CallOption) (*IssueRobotTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueRobotTokenResponse)
+	err := c.cc.Invoke(ctx, FleetAdmin_IssueRobotToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FleetAdminServer is the server API for FleetAdmin service.
+// All implementations must embed UnimplementedFleetAdminServer
+// for forward compatibility.
+//
+// for the ops dashboard, so it never has to read the robots table itself
+type FleetAdminServer interface {
+	ListRobots(context.Context, *ListRobotsRequest) (*ListRobotsResponse, error)
+	GetRobot(context.Context, *GetRobotRequest) (*GetRobotResponse, error)
+	DrainRobot(context.Context, *DrainRobotRequest) (*DrainRobotResponse, error)
+	RecallRobot(context.Context, *RecallRobotRequest) (*RecallRobotResponse, error)
+	ForceUnassign(context.Context, *ForceUnassignRequest) (*ForceUnassignResponse, error)
+	RegisterRobot(context.Context, *RegisterRobotRequest) (*RegisterRobotResponse, error)
+	IssueRobotToken(context.Context, *IssueRobotTokenRequest) (*IssueRobotTokenResponse, error)
+	mustEmbedUnimplementedFleetAdminServer()
+}
+
+// UnimplementedFleetAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFleetAdminServer struct{}
+
+func (UnimplementedFleetAdminServer) ListRobots(context.Context, *ListRobotsRequest) (*ListRobotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRobots not implemented")
+}
+func (UnimplementedFleetAdminServer) GetRobot(context.Context, *GetRobotRequest) (*GetRobotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRobot not implemented")
+}
+func (UnimplementedFleetAdminServer) DrainRobot(context.Context, *DrainRobotRequest) (*DrainRobotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainRobot not implemented")
+}
+func (UnimplementedFleetAdminServer) RecallRobot(context.Context, *RecallRobotRequest) (*RecallRobotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecallRobot not implemented")
+}
+func (UnimplementedFleetAdminServer) ForceUnassign(context.Context, *ForceUnassignRequest) (*ForceUnassignResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceUnassign not implemented")
+}
+func (UnimplementedFleetAdminServer) RegisterRobot(context.Context, *RegisterRobotRequest) (*RegisterRobotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterRobot not implemented")
+}
+func (UnimplementedFleetAdminServer) IssueRobotToken(context.Context, *IssueRobotTokenRequest) (*IssueRobotTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueRobotToken not implemented")
+}
+func (UnimplementedFleetAdminServer) mustEmbedUnimplementedFleetAdminServer() {}
+func (UnimplementedFleetAdminServer) testEmbeddedByValue()                    {}
+
+// UnsafeFleetAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FleetAdminServer will
+// result in compilation errors.
+type UnsafeFleetAdminServer interface {
+	mustEmbedUnimplementedFleetAdminServer()
+}
+
+func RegisterFleetAdminServer(s grpc.ServiceRegistrar, srv FleetAdminServer) {
+	// If the following call pancis, it indicates UnimplementedFleetAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FleetAdmin_ServiceDesc, srv)
+}
+
+func _FleetAdmin_ListRobots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRobotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).ListRobots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_ListRobots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).ListRobots(ctx, req.(*ListRobotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_GetRobot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRobotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).GetRobot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_GetRobot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).GetRobot(ctx, req.(*GetRobotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_DrainRobot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRobotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).DrainRobot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_DrainRobot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).DrainRobot(ctx, req.(*DrainRobotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_RecallRobot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecallRobotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).RecallRobot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_RecallRobot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).RecallRobot(ctx, req.(*RecallRobotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_ForceUnassign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceUnassignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).ForceUnassign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_ForceUnassign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).ForceUnassign(ctx, req.(*ForceUnassignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_RegisterRobot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRobotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).RegisterRobot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_RegisterRobot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).RegisterRobot(ctx, req.(*RegisterRobotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetAdmin_IssueRobotToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueRobotTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetAdminServer).IssueRobotToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetAdmin_IssueRobotToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetAdminServer).IssueRobotToken(ctx, req.(*IssueRobotTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FleetAdmin_ServiceDesc is the grpc.ServiceDesc for FleetAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FleetAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "order_service.FleetAdmin",
+	HandlerType: (*FleetAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRobots",
+			Handler:    _FleetAdmin_ListRobots_Handler,
+		},
+		{
+			MethodName: "GetRobot",
+			Handler:    _FleetAdmin_GetRobot_Handler,
+		},
+		{
+			MethodName: "DrainRobot",
+			Handler:    _FleetAdmin_DrainRobot_Handler,
+		},
+		{
+			MethodName: "RecallRobot",
+			Handler:    _FleetAdmin_RecallRobot_Handler,
+		},
+		{
+			MethodName: "ForceUnassign",
+			Handler:    _FleetAdmin_ForceUnassign_Handler,
+		},
+		{
+			MethodName: "RegisterRobot",
+			Handler:    _FleetAdmin_RegisterRobot_Handler,
+		},
+		{
+			MethodName: "IssueRobotToken",
+			Handler:    _FleetAdmin_IssueRobotToken_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/order_service.proto",